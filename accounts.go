@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/term"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// accountAliases are the configured accounts, in the order they are
+// matched against an address.
+var accountAliases = []string{"deployer", "user"}
+
+// accountSource is where the key of an alias comes from. spec is
+// keystore:<file>, mnemonic:<hd path>, clef:<address> or the address of a
+// file in KEYSTORE_DIR; without one the raw hex privateKey is used.
+type accountSource struct {
+	spec       string
+	privateKey string
+}
+
+func accountSources(cfg config) map[string]accountSource {
+	return map[string]accountSource{
+		"deployer": {spec: cfg.DeployerAccount, privateKey: cfg.PrivateKey},
+		"user":     {spec: cfg.UserAccount, privateKey: cfg.UserPrivateKey},
+	}
+}
+
+// accountAddress returns the address of an alias without unlocking its
+// key, so read-only commands never ask for a passphrase.
+func accountAddress(cfg config, alias string) (common.Address, error) {
+	src, ok := accountSources(cfg)[alias]
+	if !ok {
+		return common.Address{}, fmt.Errorf("unknown account %q", alias)
+	}
+	switch {
+	case common.IsHexAddress(src.spec):
+		return common.HexToAddress(src.spec), nil
+	case strings.HasPrefix(src.spec, "keystore:"):
+		return erc20kit.KeystoreAddress(strings.TrimPrefix(src.spec, "keystore:"))
+	case strings.HasPrefix(src.spec, "clef:"):
+		return parseClefSpec(src.spec)
+	}
+	signer, err := openAlias(cfg, alias)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return signer.Address(), nil
+}
+
+// openAlias unlocks the key of an alias.
+func openAlias(cfg config, alias string) (erc20kit.Signer, error) {
+	src, ok := accountSources(cfg)[alias]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %q", alias)
+	}
+	var (
+		signer erc20kit.Signer
+		err    error
+	)
+	switch {
+	case common.IsHexAddress(src.spec):
+		signer, err = openKeystoreAddress(cfg, common.HexToAddress(src.spec))
+	case strings.HasPrefix(src.spec, "keystore:"):
+		signer, err = openKeystore(cfg, strings.TrimPrefix(src.spec, "keystore:"))
+	case strings.HasPrefix(src.spec, "mnemonic:"):
+		if cfg.Mnemonic == "" {
+			return nil, fmt.Errorf("account %q: MNEMONIC is not set", alias)
+		}
+		signer, err = erc20kit.MnemonicSigner(cfg.Mnemonic, cfg.MnemonicPassword, strings.TrimPrefix(src.spec, "mnemonic:"))
+	case strings.HasPrefix(src.spec, "clef:"):
+		var address common.Address
+		if address, err = parseClefSpec(src.spec); err == nil {
+			signer, err = openClef(cfg, address)
+		}
+	case src.spec != "":
+		return nil, fmt.Errorf("account %q: unknown account spec %q, want keystore:<file>, mnemonic:<hd path>, clef:<address> or an address", alias, src.spec)
+	case src.privateKey != "":
+		signer, err = erc20kit.HexSigner(src.privateKey)
+	default:
+		return nil, fmt.Errorf("no key configured for account %q: set %s_ACCOUNT", alias, strings.ToUpper(alias))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("account %q: %w", alias, err)
+	}
+	return signer, nil
+}
+
+func parseClefSpec(spec string) (common.Address, error) {
+	address := strings.TrimPrefix(spec, "clef:")
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid clef account %q", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+func openClef(cfg config, address common.Address) (erc20kit.Signer, error) {
+	if cfg.ClefURL == "" {
+		return nil, errors.New("CLEF_URL is not set")
+	}
+	return erc20kit.DialClef(context.Background(), cfg.ClefURL, address)
+}
+
+func openKeystoreAddress(cfg config, address common.Address) (erc20kit.Signer, error) {
+	path, err := erc20kit.FindKeystore(cfg.KeystoreDir, address)
+	if err != nil {
+		return nil, err
+	}
+	return openKeystore(cfg, path)
+}
+
+func openKeystore(cfg config, path string) (erc20kit.Signer, error) {
+	passphrase := cfg.KeystorePassword
+	if passphrase == "" {
+		address, err := erc20kit.KeystoreAddress(path)
+		if err != nil {
+			return nil, err
+		}
+		if passphrase, err = promptPassphrase(fmt.Sprintf("passphrase for %s: ", address.Hex())); err != nil {
+			return nil, err
+		}
+	}
+	return erc20kit.KeystoreSigner(path, passphrase)
+}
+
+// promptPassphrase reads a passphrase from the terminal without echoing it.
+func promptPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("keystore is locked: set KEYSTORE_PASSWORD or run in a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+// resolveAddress accepts either an account alias or a hex address.
+func resolveAddress(cfg config, s string) (common.Address, error) {
+	if common.IsHexAddress(s) {
+		return common.HexToAddress(s), nil
+	}
+	return accountAddress(cfg, s)
+}
+
+// resolveSigner returns a transacting account for an alias or an address.
+// An address signs with the configured alias that has it or, failing that,
+// with its file in KEYSTORE_DIR or through Clef.
+func resolveSigner(ctx context.Context, cfg config, client *erc20kit.Pool, s string) (*erc20kit.Account, error) {
+	signer, err := openSigner(cfg, s)
+	if err != nil {
+		return nil, err
+	}
+	// never sign for a chain other than the one the profile names
+	if err := cfg.Network.CheckChain(ctx, client); err != nil {
+		return nil, err
+	}
+	account, err := erc20kit.NewAccount(ctx, client, signer)
+	if err != nil {
+		return nil, fmt.Errorf("account %q: %w", s, err)
+	}
+	account.LowFunds = func(tx *types.Transaction, maxCost, balance *big.Int) {
+		warnLowFunds(cfg.Network.Currency, account.Address, maxCost, balance)
+	}
+	return account, nil
+}
+
+// warnLowFunds says that a transaction may fail for want of gas money.
+// The node has the final word: the fees actually paid are usually below
+// the maximum.
+func warnLowFunds(currency string, address common.Address, maxCost, balance *big.Int) {
+	fmt.Fprintf(os.Stderr, "warning: %s has %s %s, the transaction may cost up to %s\n",
+		address.Hex(), erc20kit.ToDecimal(balance, 18), currency, erc20kit.ToDecimal(maxCost, 18))
+}
+
+func openSigner(cfg config, s string) (erc20kit.Signer, error) {
+	if !common.IsHexAddress(s) {
+		return openAlias(cfg, s)
+	}
+	address := common.HexToAddress(s)
+	for _, alias := range accountAliases {
+		if configured, err := accountAddress(cfg, alias); err == nil && configured == address {
+			return openAlias(cfg, alias)
+		}
+	}
+	signer, err := openKeystoreAddress(cfg, address)
+	if err != nil && cfg.ClefURL != "" {
+		signer, err = openClef(cfg, address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign as %s: %w", address.Hex(), err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// addApprovalFlags are the flags of the commands that scan Approval logs.
+func addApprovalFlags(fs *flag.FlagSet, owner string) (*string, *uint64) {
+	return fs.String("owner", owner, "owner alias or address"),
+		fs.Uint64("from-block", 0, "first block to look for approvals in, usually the token's deployment block")
+}
+
+// runApprovals lists the spenders an owner has approved, from the token's
+// Approval logs, with what each may still spend.
+func runApprovals(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("approvals", cfg)
+	owner, fromBlock := addApprovalFlags(fs, "user")
+	all := fs.Bool("all", false, "also list spenders whose allowance is back to zero")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	ownerAddress, err := resolveAddress(cfg, *owner)
+	if err != nil {
+		return err
+	}
+	approvals, err := erc20kit.Approvals(ctx, ts.client, ts.address, ownerAddress, *fromBlock)
+	if err != nil {
+		return err
+	}
+
+	listed := 0
+	for _, a := range approvals {
+		if a.Allowance.Sign() == 0 && !*all {
+			continue
+		}
+		fmt.Printf("%s  %-24s  last approved in block %d\n", a.Spender.Hex(), ts.amount(a.Allowance), a.Block)
+		listed++
+	}
+	if listed == 0 {
+		fmt.Printf("%s has no open allowances on %s\n", ownerAddress.Hex(), ts.symbol)
+	}
+	return nil
+}
+
+// runRevokeAll sets every open allowance of an owner to zero.
+func runRevokeAll(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("revoke-all", cfg)
+	owner, fromBlock := addApprovalFlags(fs, "user")
+	tf := addTxFlags(fs, cfg)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	ownerAddress, err := resolveAddress(cfg, *owner)
+	if err != nil {
+		return err
+	}
+	approvals, err := erc20kit.Approvals(ctx, ts.client, ts.address, ownerAddress, *fromBlock)
+	if err != nil {
+		return err
+	}
+	var spenders []common.Address
+	for _, a := range approvals {
+		if a.Allowance.Sign() > 0 {
+			fmt.Printf("revoking %s of %s\n", ts.amount(a.Allowance), a.Spender.Hex())
+			spenders = append(spenders, a.Spender)
+		}
+	}
+	if len(spenders) == 0 {
+		fmt.Printf("%s has no open allowances on %s\n", ownerAddress.Hex(), ts.symbol)
+		return nil
+	}
+
+	account, err := resolveSigner(ctx, cfg, ts.client, ownerAddress.Hex())
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(account); err != nil {
+		return err
+	}
+	if tf.dryRun {
+		for _, spender := range spenders {
+			if err := simulateTx(ctx, ts, account, "approve", spender, big.NewInt(0)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	allowances, err := erc20kit.NewAllowances(account, ts.address)
+	if err != nil {
+		return err
+	}
+	sent, err := allowances.Revoke(ctx, spenders)
+	// the revocations that went out are waited for even if one failed
+	for _, tx := range sent {
+		if waitErr := waitForTx(ctx, ts.client, tx, tf); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return err
+}
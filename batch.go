@@ -0,0 +1,462 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// runBatchTransfer pays every row of a CSV or JSON file. Progress goes to a
+// journal next to the file, and a rerun with the same file picks up where
+// the last one stopped without paying anyone twice.
+func runBatchTransfer(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("batch-transfer", cfg)
+	from := fs.String("from", "deployer", "sending account alias")
+	file := fs.String("file", "", "CSV or JSON file of recipient,amount rows, amounts in whole tokens")
+	format := fs.String("format", "", "csv or json, taken from the file extension when empty")
+	journalPath := fs.String("journal", "", "progress journal (defaults to <file>.journal)")
+	maxPending := fs.Int("max-pending", 50, "transactions in flight before waiting for their receipts")
+	tf := addTxFlags(fs, cfg)
+	fs.Parse(args)
+
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+	if *journalPath == "" {
+		*journalPath = *file + ".journal"
+	}
+	if *maxPending < 1 {
+		*maxPending = 1
+	}
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	sender, err := resolveSigner(ctx, cfg, ts.client, *from)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(sender); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	payments, err := erc20kit.ReadPayments(f, *format, ts.decimals)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", *file, err)
+	}
+
+	if tf.dryRun {
+		return dryRunBatch(ctx, ts, sender, payments)
+	}
+
+	journal, err := erc20kit.OpenJournal(*journalPath)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	b := &batch{ts: ts, sender: sender, journal: journal, wait: tf.wait}
+	if err := b.checkJournal(payments); err != nil {
+		return err
+	}
+	fmt.Printf("%d payments from %s, journal %s\n", len(payments), sender.Address.Hex(), *journalPath)
+
+	inflight, err := b.resume(ctx, payments)
+	if err != nil {
+		return err
+	}
+	b.waitAll(ctx, inflight)
+
+	todo := journal.Unpaid(payments)
+	var sendErr error
+	for len(todo) > 0 && sendErr == nil {
+		n := *maxPending
+		if n > len(todo) {
+			n = len(todo)
+		}
+		var window []erc20kit.JournalEntry
+		for _, p := range todo[:n] {
+			entry, err := b.send(ctx, p)
+			if err != nil {
+				sendErr = fmt.Errorf("row %d: %w", p.Row, err)
+				break
+			}
+			window = append(window, entry)
+		}
+		b.waitAll(ctx, window)
+		todo = todo[n:]
+	}
+
+	complete, err := b.reconcile(ctx, payments)
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return fmt.Errorf("stopped sending: %w; rerun to resume", sendErr)
+	}
+	if !complete {
+		return errors.New("some payments are not complete; rerun to resume")
+	}
+	return nil
+}
+
+// dryRunBatch simulates every payment on its own, without looking at the
+// journal, and checks that the sender holds enough tokens for all of them.
+func dryRunBatch(ctx context.Context, ts *tokenSession, sender *erc20kit.Account, payments []erc20kit.Payment) error {
+	parsed, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	var (
+		total, cost = new(big.Int), new(big.Int)
+		failed      []string
+	)
+	for _, p := range payments {
+		total.Add(total, p.Amount)
+		sim, err := sender.Simulate(ctx, ts.address, parsed, "transfer", p.Recipient, p.Amount)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("row %d %s: %v", p.Row, p.Recipient.Hex(), err))
+			continue
+		}
+		cost.Add(cost, sim.Cost)
+	}
+	balance, err := ts.instance.BalanceOf(&bind.CallOpts{Context: ctx}, sender.Address)
+	if err != nil {
+		return fmt.Errorf("balance of %s: %w", sender.Address.Hex(), err)
+	}
+
+	fmt.Println("dry run:")
+	fmt.Printf("  total:   %d payments, %s\n", len(payments), ts.amount(total))
+	fmt.Printf("  balance: %s\n", ts.amount(balance))
+	fmt.Printf("  estimated cost: %s %s\n", erc20kit.ToDecimal(cost, 18), ts.network.Currency)
+	printRows("would fail", failed)
+	if balance.Cmp(total) < 0 {
+		return fmt.Errorf("dry run: balance is %s short", ts.amount(new(big.Int).Sub(total, balance)))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("dry run: %d payments would fail", len(failed))
+	}
+	return nil
+}
+
+type batch struct {
+	ts      *tokenSession
+	sender  *erc20kit.Account
+	journal *erc20kit.Journal
+	wait    erc20kit.WaitOptions
+}
+
+// checkJournal refuses a journal written for another token or sender,
+// whose entries would say nothing about this run.
+func (b *batch) checkJournal(payments []erc20kit.Payment) error {
+	for _, p := range payments {
+		entry, ok := b.journal.Entry(p.Key)
+		if !ok {
+			continue
+		}
+		if entry.Token != b.ts.address.Hex() || entry.From != b.sender.Address.Hex() {
+			return fmt.Errorf("journal is for token %s sent by %s, not token %s sent by %s",
+				entry.Token, entry.From, b.ts.address.Hex(), b.sender.Address.Hex())
+		}
+	}
+	return nil
+}
+
+func (b *batch) entry(p erc20kit.Payment, status string) erc20kit.JournalEntry {
+	return erc20kit.JournalEntry{
+		Key:       p.Key,
+		Token:     b.ts.address.Hex(),
+		From:      b.sender.Address.Hex(),
+		Recipient: p.Recipient.Hex(),
+		Amount:    p.Amount.String(),
+		Status:    status,
+	}
+}
+
+// resume settles every payment a previous run left as sent. Transactions
+// that the node lost but whose nonce is still free are rebroadcast from the
+// journal, in nonce order so none is left behind a gap. Entries that cannot
+// be looked up or rebroadcast are recorded as failed, and the ones still in
+// flight are returned.
+func (b *batch) resume(ctx context.Context, payments []erc20kit.Payment) ([]erc20kit.JournalEntry, error) {
+	var inflight []erc20kit.JournalEntry
+	for _, entry := range b.journal.Sent(payments) {
+		pending, status, err := b.resumeEntry(ctx, entry)
+		if err != nil {
+			entry.Status, entry.Error = status, err.Error()
+			fmt.Printf("tx %s: %s: %v\n", entry.TxHash, status, err)
+			if err := b.journal.Record(entry); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if pending {
+			inflight = append(inflight, entry)
+		}
+	}
+	return inflight, nil
+}
+
+// resumeEntry settles, finds or rebroadcasts the transaction of a sent entry
+// and reports whether it is in flight. On failure it also returns the
+// status to record: StatusSendFailed when the transaction cannot be mined
+// any more, StatusUnknown when that cannot be told.
+func (b *batch) resumeEntry(ctx context.Context, entry erc20kit.JournalEntry) (bool, string, error) {
+	hash := common.HexToHash(entry.TxHash)
+	receipt, err := b.ts.client.TransactionReceipt(ctx, hash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return false, erc20kit.StatusUnknown, fmt.Errorf("receipt: %w", err)
+	}
+	if receipt != nil {
+		if err := b.settle(ctx, entry, receipt); err != nil {
+			return false, erc20kit.StatusUnknown, fmt.Errorf("journal: %w", err)
+		}
+		return false, "", nil
+	}
+
+	if _, _, err := b.ts.client.TransactionByHash(ctx, hash); err == nil {
+		return true, "", nil
+	} else if !errors.Is(err, ethereum.NotFound) {
+		return false, erc20kit.StatusUnknown, fmt.Errorf("lookup: %w", err)
+	}
+
+	// the transaction is gone: unless its nonce was used meanwhile, it can
+	// never be mined
+	unused := func() (string, error) {
+		next, err := b.ts.client.PendingNonceAt(ctx, b.sender.Address)
+		if err != nil {
+			return erc20kit.StatusUnknown, err
+		}
+		if next > entry.Nonce {
+			return erc20kit.StatusUnknown, fmt.Errorf("tx is gone and nonce %d was used by another transaction", entry.Nonce)
+		}
+		return erc20kit.StatusSendFailed, nil
+	}
+	status, err := unused()
+	if err != nil {
+		return false, status, err
+	}
+	raw, err := hexutil.Decode(entry.RawTx)
+	if err != nil {
+		return false, status, fmt.Errorf("journal entry %s: %w", entry.Key, err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return false, status, fmt.Errorf("journal entry %s: %w", entry.Key, err)
+	}
+	if err := b.ts.client.SendTransaction(ctx, tx); err != nil {
+		err = fmt.Errorf("rebroadcast: %w", err)
+		if !erc20kit.IsRejected(err) {
+			return false, erc20kit.StatusUnknown, err
+		}
+		// a rejected nonce may have gone to this very transaction
+		if status, nonceErr := unused(); nonceErr != nil {
+			return false, status, fmt.Errorf("%v; %v", err, nonceErr)
+		}
+		return false, erc20kit.StatusSendFailed, err
+	}
+	fmt.Printf("rebroadcast %s to %s\n", entry.TxHash, entry.Recipient)
+	return true, "", nil
+}
+
+// send signs the transfer, journals it and only then broadcasts it.
+func (b *batch) send(ctx context.Context, p erc20kit.Payment) (erc20kit.JournalEntry, error) {
+	entry := b.entry(p, erc20kit.StatusSent)
+	_, err := b.sender.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.NoSend = true
+		tx, err := b.ts.instance.Transfer(opts, p.Recipient, p.Amount)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		entry.Nonce = tx.Nonce()
+		entry.TxHash = tx.Hash().Hex()
+		entry.RawTx = hexutil.Encode(raw)
+		if err := b.journal.Record(entry); err != nil {
+			return nil, fmt.Errorf("journal: %w", err)
+		}
+		if err := b.ts.client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	})
+	if err != nil {
+		// once journalled the broadcast may have reached the node despite
+		// the error, so unless the node turned it down the entry stays
+		// sent for the next run to settle
+		if entry.TxHash != "" && !erc20kit.IsRejected(err) {
+			return entry, err
+		}
+		failed := b.entry(p, erc20kit.StatusSendFailed)
+		failed.Nonce, failed.TxHash = entry.Nonce, entry.TxHash
+		failed.Error = err.Error()
+		if recordErr := b.journal.Record(failed); recordErr != nil {
+			return entry, fmt.Errorf("%v (journal: %v)", err, recordErr)
+		}
+		return entry, err
+	}
+	fmt.Printf("row %d: sent %s to %s, tx %s\n", p.Row, b.ts.amount(p.Amount), p.Recipient.Hex(), entry.TxHash)
+	return entry, nil
+}
+
+// waitAll waits for the receipts of entries. The ones that time out stay
+// sent in the journal and are picked up by the next run.
+func (b *batch) waitAll(ctx context.Context, entries []erc20kit.JournalEntry) {
+	for _, entry := range entries {
+		receipt, err := erc20kit.WaitForReceipt(ctx, b.ts.client, common.HexToHash(entry.TxHash), b.wait)
+		if receipt == nil {
+			fmt.Printf("tx %s: %v\n", entry.TxHash, err)
+			continue
+		}
+		if err := b.settle(ctx, entry, receipt); err != nil {
+			fmt.Printf("tx %s: journal: %v\n", entry.TxHash, err)
+		}
+	}
+}
+
+func (b *batch) settle(ctx context.Context, entry erc20kit.JournalEntry, receipt *types.Receipt) error {
+	entry.Block = receipt.BlockNumber.Uint64()
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		entry.Status = erc20kit.StatusMined
+	} else {
+		entry.Status = erc20kit.StatusReverted
+		entry.Error = "transfer reverted"
+		if reason, err := b.revertReason(ctx, entry, receipt); err == nil {
+			entry.Error += ": " + reason.Error()
+		}
+	}
+	entry.RawTx = ""
+	return b.journal.Record(entry)
+}
+
+// revertReason replays the journalled transaction of a reverted payment.
+func (b *batch) revertReason(ctx context.Context, entry erc20kit.JournalEntry, receipt *types.Receipt) (*erc20kit.RevertError, error) {
+	raw, err := hexutil.Decode(entry.RawTx)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	abis, err := errorABIs("")
+	if err != nil {
+		return nil, err
+	}
+	return erc20kit.ExplainRevert(ctx, b.ts.client, tx, receipt.BlockNumber, abis...)
+}
+
+// reconcile prints planned against journalled and on-chain results. Every
+// mined payment is checked for a matching Transfer event in its receipt.
+// It reports whether all payments are complete.
+func (b *batch) reconcile(ctx context.Context, payments []erc20kit.Payment) (bool, error) {
+	var (
+		planned, paid           = new(big.Int), new(big.Int)
+		paidCount, mismatches   int
+		pending, failed, unsent []string
+	)
+	for _, p := range payments {
+		planned.Add(planned, p.Amount)
+		row := fmt.Sprintf("row %d %s %s", p.Row, p.Recipient.Hex(), b.ts.amount(p.Amount))
+
+		entry, ok := b.journal.Entry(p.Key)
+		switch {
+		case !ok:
+			unsent = append(unsent, row)
+		case entry.Status == erc20kit.StatusMined:
+			verified, err := b.transferred(ctx, p, common.HexToHash(entry.TxHash))
+			if err != nil {
+				return false, err
+			}
+			if !verified {
+				mismatches++
+				failed = append(failed, fmt.Sprintf("%s: tx %s has no matching Transfer event", row, entry.TxHash))
+				continue
+			}
+			paidCount++
+			paid.Add(paid, p.Amount)
+		case entry.Status == erc20kit.StatusSent:
+			pending = append(pending, fmt.Sprintf("%s: tx %s", row, entry.TxHash))
+		default:
+			failed = append(failed, fmt.Sprintf("%s: %s %s", row, entry.Status, entry.Error))
+		}
+	}
+
+	balance, err := b.ts.instance.BalanceOf(&bind.CallOpts{Context: ctx}, b.sender.Address)
+	if err != nil {
+		return false, fmt.Errorf("balance of %s: %w", b.sender.Address.Hex(), err)
+	}
+
+	fmt.Println()
+	fmt.Println("reconciliation:")
+	fmt.Printf("  planned: %d payments, %s\n", len(payments), b.ts.amount(planned))
+	fmt.Printf("  paid:    %d payments, %s, verified against Transfer events\n", paidCount, b.ts.amount(paid))
+	fmt.Printf("  left:    %s\n", b.ts.amount(new(big.Int).Sub(planned, paid)))
+	fmt.Printf("  sender balance: %s\n", b.ts.amount(balance))
+	printRows("pending", pending)
+	printRows("failed", failed)
+	printRows("not sent", unsent)
+	if mismatches > 0 {
+		fmt.Printf("  %d mined transactions did not transfer what was planned, check them by hand\n", mismatches)
+	}
+	return paidCount == len(payments), nil
+}
+
+func printRows(label string, rows []string) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Printf("  %s: %d\n", label, len(rows))
+	for _, row := range rows {
+		fmt.Printf("    %s\n", row)
+	}
+}
+
+// transferred reports whether the receipt of txHash has a Transfer event
+// from the sender paying p in full.
+func (b *batch) transferred(ctx context.Context, p erc20kit.Payment, txHash common.Hash) (bool, error) {
+	receipt, err := b.ts.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return false, fmt.Errorf("receipt of %s: %w", txHash.Hex(), err)
+	}
+	for _, log := range receipt.Logs {
+		if log.Address != b.ts.address || len(log.Topics) == 0 || log.Topics[0] != erc20kit.TransferTopic {
+			continue
+		}
+		event, err := b.ts.instance.ParseTransfer(*log)
+		if err != nil {
+			continue
+		}
+		if event.From == b.sender.Address && event.To == p.Recipient && event.Value.Cmp(p.Amount) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,1070 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/index"
+	"gb-sc-homework/erc20kit/registry"
+	"gb-sc-homework/erc20kit/watch"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(cfg config, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"balance", "balance [--token addr] [--of deployer,user,0x...]", runBalance},
+		{"transfer", "transfer [--token addr] --from deployer --to user --amount 100 [--export tx.json]", runTransfer},
+		{"approve", "approve [--token addr] --from user --spender deployer --amount 100 [--increase | --decrease] [--export tx.json]", runApprove},
+		{"transfer-from", "transfer-from [--token addr] --spender deployer --from user --to deployer --amount 10 [--export tx.json]", runTransferFrom},
+		{"permit", "permit [--token addr] --from user --spender deployer --amount 100 [--to user] [--out permit.json | --submit permit.json]", runPermit},
+		{"allowance", "allowance [--token addr] --owner user --spender deployer", runAllowance},
+		{"approvals", "approvals [--token addr] [--owner user] [--from-block N] [--all]", runApprovals},
+		{"revoke-all", "revoke-all [--token addr] [--owner user] [--from-block N]", runRevokeAll},
+		{"deploy", "deploy --name \"My Token\" --symbol MTK [--decimals 18] --supply 1000000 [--register mtk] [--export tx.json]", runDeploy},
+		{"send-native", "send-native --from deployer --to user --amount 0.1 [--export tx.json]", runSendNative},
+		{"info", "info [--token addr]", runInfo},
+		{"tokens", "tokens [list | add <name> <address> | remove <name> | refresh [name...]]", runTokens},
+		{"batch-transfer", "batch-transfer [--token addr] [--from deployer] --file payments.csv [--journal payments.csv.journal]", runBatchTransfer},
+		{"index", "index [--token addr] --db token.db --from-block N [--follow]", runIndex},
+		{"ledger", "ledger [--token addr] --db token.db [--block N] [--reconcile]", runLedger},
+		{"watch", "watch [--token addr] [--events transfer,approval] [--from deployer] [--to ...] [--owner ...] [--spender ...] [--webhook url]", runWatch},
+		{"sign", "sign [--out tx.signed.json] <tx.json>", runSign},
+		{"broadcast", "broadcast [--confirmations 1] <tx.signed.json>", runBroadcast},
+		{"sign-message", "sign-message [--from user] [--hex] (<message> | --file path)", runSignMessage},
+		{"sign-typed-data", "sign-typed-data [--from user] <payload.json>", runSignTypedData},
+		{"verify", "verify --signature 0x... [--expect user] [--hex] (<message> | --file path | --typed-data payload.json)", runVerify},
+		{"revert-reason", "revert-reason [--errors-abi file] <txhash>", runRevertReason},
+		{"speedup", "speedup [--bump 10] [--every 2m] <txhash>", runSpeedup},
+		{"cancel", "cancel [--bump 10] [--every 2m] <txhash>", runCancel},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: gb-sc-homework [--network name] <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "accounts can be given as an alias (deployer, user) or a hex address")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "networks:")
+	for _, name := range erc20kit.NetworkNames() {
+		network := erc20kit.Networks[name]
+		fmt.Fprintf(os.Stderr, "  %-12s chain id %s, %s\n", name, network.ChainID, network.Currency)
+	}
+}
+
+// tokenSession bundles everything a command needs to talk to one token.
+type tokenSession struct {
+	client   *erc20kit.Pool
+	address  common.Address
+	instance *token.ERC20token
+	decimals int
+	symbol   string
+	network  *erc20kit.Network
+}
+
+// amount formats base units as whole tokens with the token's symbol.
+func (ts *tokenSession) amount(value *big.Int) string {
+	return erc20kit.ToDecimal(value, ts.decimals).String() + " " + ts.symbol
+}
+
+func newFlagSet(name string, cfg config) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	tokenAddr := fs.String("token", cfg.TokenAddress, "token symbol from the registry or contract address (defaults to TOKEN_ADDRESS)")
+	return fs, tokenAddr
+}
+
+// dial connects to every RPC endpoint of the selected network.
+func dial(ctx context.Context, cfg config) (*erc20kit.Pool, error) {
+	return erc20kit.DialPool(ctx, cfg.Network.RPCURLs, erc20kit.PoolOptions{})
+}
+
+func openToken(ctx context.Context, cfg config, tokenAddr string) (*tokenSession, error) {
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := resolveToken(ctx, cfg, client, tokenAddr)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	instance, err := token.NewERC20token(meta.Address, client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &tokenSession{
+		client:   client,
+		address:  meta.Address,
+		instance: instance,
+		decimals: int(meta.Decimals),
+		symbol:   meta.Symbol,
+		network:  &cfg.Network,
+	}, nil
+}
+
+// resolveToken accepts a name from the token registry or a contract
+// address. Registered tokens come from the cache; other addresses are
+// read from the chain.
+func resolveToken(ctx context.Context, cfg config, client *erc20kit.Pool, s string) (*registry.Token, error) {
+	if s == "" {
+		return nil, errors.New("no token given: pass --token or set TOKEN_ADDRESS")
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", erc20kit.ErrChainID, err)
+	}
+	reg, err := registry.Load(cfg.TokenRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	if common.IsHexAddress(s) {
+		address := common.HexToAddress(s)
+		if _, meta, ok := reg.ByAddress(chainID, address); ok {
+			return meta, nil
+		}
+		return registry.Fetch(ctx, client, address)
+	}
+	if meta, ok := reg.Lookup(chainID, s); ok {
+		return meta, nil
+	}
+	return nil, fmt.Errorf("unknown token %q on chain %s; register it with `tokens add %s <address>`", s, chainID, s)
+}
+
+// resolveAddresses resolves a comma separated list of aliases and
+// addresses. An empty list gives nil.
+func resolveAddresses(cfg config, list string) ([]common.Address, error) {
+	var addresses []common.Address
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		address, err := resolveAddress(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+func parseTxHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid transaction hash %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+func parseAmount(s string, decimals int) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("--amount is required")
+	}
+	amount, err := erc20kit.ToWeiExact(s, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if amount.Sign() == 0 {
+		return nil, fmt.Errorf("amount must be positive, got %s", s)
+	}
+	return amount, nil
+}
+
+// txFlags are shared by every command that sends a transaction.
+type txFlags struct {
+	wait      erc20kit.WaitOptions
+	gas       string
+	dryRun    bool
+	errorsABI string
+	export    string
+	network   *erc20kit.Network
+}
+
+func addTxFlags(fs *flag.FlagSet, cfg config) *txFlags {
+	tf := addWaitFlags(fs, cfg)
+	fs.StringVar(&tf.gas, "gas", cfg.Network.Gas, "gas strategy: auto, legacy, eip1559 or fixed:<gwei>, optionally *<multiplier> (defaults to the network's)")
+	fs.BoolVar(&tf.dryRun, "dry-run", false, "simulate against pending state and report the result, gas and cost without sending")
+	return tf
+}
+
+// printWaitError reports a node failure while waiting for a receipt,
+// which the wait retries.
+func printWaitError(err error) {
+	fmt.Fprintln(os.Stderr, "wait:", err)
+}
+
+// addWaitFlags are the txFlags of commands that send an already signed
+// transaction.
+func addWaitFlags(fs *flag.FlagSet, cfg config) *txFlags {
+	tf := &txFlags{network: &cfg.Network}
+	fs.Uint64Var(&tf.wait.Confirmations, "confirmations", 1, "blocks to wait for, counting the one the tx is mined in")
+	fs.DurationVar(&tf.wait.Timeout, "timeout", 5*time.Minute, "how long to wait for the receipt")
+	tf.wait.OnError = printWaitError
+	fs.StringVar(&tf.errorsABI, "errors-abi", "", "ABI JSON file declaring custom errors to decode reverts with")
+	return tf
+}
+
+// addExportFlag lets a command write its transaction unsigned to a file
+// for `sign` instead of sending it.
+func (tf *txFlags) addExportFlag(fs *flag.FlagSet) {
+	fs.StringVar(&tf.export, "export", "", "write the transaction unsigned to this file for `sign`, instead of sending it")
+}
+
+// apply sets the gas strategy on the account that signs.
+func (tf *txFlags) apply(account *erc20kit.Account) error {
+	strategy, err := erc20kit.ParseGasStrategy(tf.gas)
+	if err != nil {
+		return err
+	}
+	account.Gas = strategy
+	return nil
+}
+
+// exportTx is what --export does instead of sending: it prepares the
+// transaction for from, which needs no key here, and writes it unsigned.
+func exportTx(ctx context.Context, cfg config, client *erc20kit.Pool, tf *txFlags, from, description string, send func(opts *bind.TransactOpts) (*types.Transaction, error)) error {
+	sender, err := resolveAddress(cfg, from)
+	if err != nil {
+		return err
+	}
+	strategy, err := erc20kit.ParseGasStrategy(tf.gas)
+	if err != nil {
+		return err
+	}
+	options := erc20kit.PrepareOptions{Gas: strategy, GasLimitMargin: erc20kit.DefaultGasLimitMargin}
+	unsigned, err := erc20kit.PrepareTx(ctx, client, sender, options, send)
+	if err != nil {
+		return err
+	}
+	unsigned.Description = description
+	if err := writeJSONFile(tf.export, unsigned); err != nil {
+		return err
+	}
+	fmt.Printf("unsigned tx from %s with nonce %d written to %s\n", sender.Hex(), unsigned.Tx.Nonce, tf.export)
+	return nil
+}
+
+// simulateTx is what --dry-run does instead of sending: it runs method
+// against pending state and prints the outcome.
+func simulateTx(ctx context.Context, ts *tokenSession, account *erc20kit.Account, method string, args ...interface{}) error {
+	return simulateCall(ctx, ts, account, token.ERC20tokenMetaData, method, args...)
+}
+
+// simulateCall is simulateTx for a method of another interface of the
+// token, such as IERC20Permit.
+func simulateCall(ctx context.Context, ts *tokenSession, account *erc20kit.Account, contract *bind.MetaData, method string, args ...interface{}) error {
+	parsed, err := contract.GetAbi()
+	if err != nil {
+		return err
+	}
+	sim, err := account.Simulate(ctx, ts.address, parsed, method, args...)
+	if err != nil {
+		return fmt.Errorf("dry run %s: %w", method, err)
+	}
+	fmt.Printf("dry run %s: ok, returns %v\n", method, sim.Return)
+	fmt.Printf("estimated gas: %d (limit %d)\n", sim.Gas, sim.GasLimit)
+	fmt.Printf("estimated cost: %s %s (at most %s)\n", erc20kit.ToDecimal(sim.Cost, 18), ts.network.Currency, erc20kit.ToDecimal(sim.MaxCost, 18))
+	balance, err := account.Balance(ctx)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(sim.MaxCost) < 0 {
+		warnLowFunds(ts.network.Currency, account.Address, sim.MaxCost, balance)
+	}
+	return nil
+}
+
+// previewTx is --dry-run for transactions that are not a token call,
+// which simulateTx cannot run: it builds the transaction without signing
+// it and shows it with the funds it needs.
+func previewTx(ctx context.Context, cfg config, client *erc20kit.Pool, account *erc20kit.Account, description string, send func(opts *bind.TransactOpts) (*types.Transaction, error)) error {
+	options := erc20kit.PrepareOptions{Gas: account.Gas, GasLimitMargin: account.GasLimitMargin}
+	unsigned, err := erc20kit.PrepareTx(ctx, client, account.Address, options, send)
+	if err != nil {
+		return fmt.Errorf("dry run: %w", err)
+	}
+	unsigned.Description = description
+	if err := printUnsigned(cfg, unsigned); err != nil {
+		return err
+	}
+	balance, err := account.Balance(ctx)
+	if err != nil {
+		return err
+	}
+	if maxCost := erc20kit.MaxCost(unsigned.Tx.ToTransaction()); balance.Cmp(maxCost) < 0 {
+		warnLowFunds(cfg.Network.Currency, account.Address, maxCost, balance)
+	}
+	return nil
+}
+
+// errorABIs returns the token ABI and, when path is set, the ABI of custom
+// errors to decode revert data with.
+func errorABIs(path string) ([]*abi.ABI, error) {
+	tokenABI, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	abis := []*abi.ABI{tokenABI}
+	if path == "" {
+		return abis, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	custom, err := abi.JSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return append(abis, &custom), nil
+}
+
+func waitForTx(ctx context.Context, client *erc20kit.Pool, tx *types.Transaction, tf *txFlags) error {
+	fmt.Printf("tx sent: %s\n", tx.Hash().Hex())
+	if url := tf.network.TxURL(tx.Hash()); url != "" {
+		fmt.Printf("  %s\n", url)
+	}
+	receipt, err := erc20kit.WaitForReceipt(ctx, client, tx.Hash(), tf.wait)
+	if errors.Is(err, erc20kit.ErrReverted) {
+		return fmt.Errorf("tx %s: %w", tx.Hash().Hex(), explainRevert(ctx, client, tx, receipt, tf.errorsABI))
+	}
+	if err != nil {
+		return fmt.Errorf("tx %s: %w", tx.Hash().Hex(), err)
+	}
+	fmt.Printf("tx mined in block %s: success, gas used %d\n", receipt.BlockNumber, receipt.GasUsed)
+	return nil
+}
+
+// explainRevert returns ErrReverted with the reason recovered by replaying
+// tx, or with why the reason could not be recovered.
+func explainRevert(ctx context.Context, client *erc20kit.Pool, tx *types.Transaction, receipt *types.Receipt, errorsABI string) error {
+	fmt.Printf("tx mined in block %s: reverted, gas used %d\n", receipt.BlockNumber, receipt.GasUsed)
+	abis, err := errorABIs(errorsABI)
+	if err != nil {
+		return fmt.Errorf("%w (load errors ABI: %v)", erc20kit.ErrReverted, err)
+	}
+	reason, err := erc20kit.ExplainRevert(ctx, client, tx, receipt.BlockNumber, abis...)
+	if err != nil {
+		return fmt.Errorf("%w (%v)", erc20kit.ErrReverted, err)
+	}
+	return fmt.Errorf("%w: %v", erc20kit.ErrReverted, reason)
+}
+
+func runRevertReason(cfg config, args []string) error {
+	fs := flag.NewFlagSet("revert-reason", flag.ExitOnError)
+	errorsABI := fs.String("errors-abi", "", "ABI JSON file declaring custom errors to decode reverts with")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: revert-reason [--errors-abi file] <txhash>")
+	}
+	hash, err := parseTxHash(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tx, _, err := client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("tx %s: %w", hash.Hex(), err)
+	}
+	receipt, err := client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("receipt of %s: %w", hash.Hex(), err)
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		fmt.Printf("tx mined in block %s: success, gas used %d\n", receipt.BlockNumber, receipt.GasUsed)
+		return nil
+	}
+	return explainRevert(ctx, client, tx, receipt, *errorsABI)
+}
+
+func runBalance(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("balance", cfg)
+	of := fs.String("of", "deployer,user", "comma separated accounts to show")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+
+	for _, name := range strings.Split(*of, ",") {
+		name = strings.TrimSpace(name)
+		address, err := resolveAddress(cfg, name)
+		if err != nil {
+			return err
+		}
+		balance, err := ts.instance.BalanceOf(&bind.CallOpts{Context: ctx}, address)
+		if err != nil {
+			return fmt.Errorf("balance of %s: %w", address.Hex(), err)
+		}
+		native, err := ts.client.BalanceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("%s balance of %s: %w", ts.network.Currency, address.Hex(), err)
+		}
+		fmt.Printf("%s (%s) balance: %s, %s %s\n", name, address.Hex(), ts.amount(balance), erc20kit.ToDecimal(native, 18), ts.network.Currency)
+	}
+	return nil
+}
+
+func runTransfer(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("transfer", cfg)
+	from := fs.String("from", "deployer", "sending account alias")
+	to := fs.String("to", "", "recipient alias or address")
+	amountStr := fs.String("amount", "", "amount in whole tokens, e.g. 100 or 0.5")
+	tf := addTxFlags(fs, cfg)
+	tf.addExportFlag(fs)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	recipient, err := resolveAddress(cfg, *to)
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(*amountStr, ts.decimals)
+	if err != nil {
+		return err
+	}
+
+	if tf.export != "" {
+		return exportTx(ctx, cfg, ts.client, tf, *from, fmt.Sprintf("transfer %s to %s", ts.amount(amount), recipient.Hex()),
+			func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return ts.instance.Transfer(opts, recipient, amount)
+			})
+	}
+	sender, err := resolveSigner(ctx, cfg, ts.client, *from)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(sender); err != nil {
+		return err
+	}
+
+	if tf.dryRun {
+		return simulateTx(ctx, ts, sender, "transfer", recipient, amount)
+	}
+	tx, err := sender.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ts.instance.Transfer(opts, recipient, amount)
+	})
+	if err != nil {
+		return err
+	}
+	return waitForTx(ctx, ts.client, tx, tf)
+}
+
+func runApprove(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("approve", cfg)
+	from := fs.String("from", "user", "owner account alias")
+	spender := fs.String("spender", "", "spender alias or address")
+	amountStr := fs.String("amount", "", "allowance in whole tokens")
+	increase := fs.Bool("increase", false, "add --amount to the current allowance instead of setting it")
+	decrease := fs.Bool("decrease", false, "subtract --amount from the current allowance instead of setting it")
+	tf := addTxFlags(fs, cfg)
+	tf.addExportFlag(fs)
+	fs.Parse(args)
+	if *increase && *decrease {
+		return errors.New("--increase and --decrease are exclusive")
+	}
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	spenderAddress, err := resolveAddress(cfg, *spender)
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(*amountStr, ts.decimals)
+	if err != nil {
+		return err
+	}
+
+	if tf.export != "" {
+		// the allowance may change before the file is signed, so only
+		// a plain approve can be exported
+		if *increase || *decrease {
+			return errors.New("--export cannot be combined with --increase or --decrease")
+		}
+		return exportTx(ctx, cfg, ts.client, tf, *from, fmt.Sprintf("approve %s for %s", ts.amount(amount), spenderAddress.Hex()),
+			func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return ts.instance.Approve(opts, spenderAddress, amount)
+			})
+	}
+	owner, err := resolveSigner(ctx, cfg, ts.client, *from)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(owner); err != nil {
+		return err
+	}
+
+	switch {
+	case tf.dryRun && *increase:
+		return simulateCall(ctx, ts, owner, token.IERC20AllowanceMetaData, "increaseAllowance", spenderAddress, amount)
+	case tf.dryRun && *decrease:
+		return simulateCall(ctx, ts, owner, token.IERC20AllowanceMetaData, "decreaseAllowance", spenderAddress, amount)
+	case tf.dryRun:
+		return simulateTx(ctx, ts, owner, "approve", spenderAddress, amount)
+	}
+
+	allowances, err := erc20kit.NewAllowances(owner, ts.address)
+	if err != nil {
+		return err
+	}
+	allowances.Wait = tf.wait
+	var sent []*types.Transaction
+	switch {
+	case *increase:
+		sent, err = allowances.Increase(ctx, spenderAddress, amount)
+	case *decrease:
+		sent, err = allowances.Decrease(ctx, spenderAddress, amount)
+	default:
+		sent, err = allowances.Set(ctx, spenderAddress, amount)
+	}
+	if err != nil {
+		return err
+	}
+	if len(sent) == 0 {
+		fmt.Printf("allowance of %s is already %s\n", spenderAddress.Hex(), ts.amount(amount))
+		return nil
+	}
+	for _, reset := range sent[:len(sent)-1] {
+		fmt.Printf("allowance reset to 0 first in tx %s\n", reset.Hash().Hex())
+	}
+	return waitForTx(ctx, ts.client, sent[len(sent)-1], tf)
+}
+
+func runTransferFrom(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("transfer-from", cfg)
+	spender := fs.String("spender", "deployer", "account alias that holds the allowance and signs")
+	from := fs.String("from", "", "owner alias or address to pull tokens from")
+	to := fs.String("to", "", "recipient alias or address")
+	amountStr := fs.String("amount", "", "amount in whole tokens")
+	tf := addTxFlags(fs, cfg)
+	tf.addExportFlag(fs)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	owner, err := resolveAddress(cfg, *from)
+	if err != nil {
+		return err
+	}
+	recipient, err := resolveAddress(cfg, *to)
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(*amountStr, ts.decimals)
+	if err != nil {
+		return err
+	}
+
+	if tf.export != "" {
+		return exportTx(ctx, cfg, ts.client, tf, *spender, fmt.Sprintf("transferFrom %s from %s to %s", ts.amount(amount), owner.Hex(), recipient.Hex()),
+			func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return ts.instance.TransferFrom(opts, owner, recipient, amount)
+			})
+	}
+	signer, err := resolveSigner(ctx, cfg, ts.client, *spender)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(signer); err != nil {
+		return err
+	}
+
+	if tf.dryRun {
+		return simulateTx(ctx, ts, signer, "transferFrom", owner, recipient, amount)
+	}
+	tx, err := signer.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ts.instance.TransferFrom(opts, owner, recipient, amount)
+	})
+	if err != nil {
+		return err
+	}
+	return waitForTx(ctx, ts.client, tx, tf)
+}
+
+func runAllowance(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("allowance", cfg)
+	owner := fs.String("owner", "user", "owner alias or address")
+	spender := fs.String("spender", "deployer", "spender alias or address")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	ownerAddress, err := resolveAddress(cfg, *owner)
+	if err != nil {
+		return err
+	}
+	spenderAddress, err := resolveAddress(cfg, *spender)
+	if err != nil {
+		return err
+	}
+
+	allowance, err := ts.instance.Allowance(&bind.CallOpts{Context: ctx}, ownerAddress, spenderAddress)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("allowance %s -> %s: %s\n", ownerAddress.Hex(), spenderAddress.Hex(), ts.amount(allowance))
+	return nil
+}
+
+func runInfo(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("info", cfg)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+
+	name, err := ts.instance.Name(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return fmt.Errorf("read name: %w", err)
+	}
+	symbol, err := ts.instance.Symbol(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return fmt.Errorf("read symbol: %w", err)
+	}
+	totalSupply, err := ts.instance.TotalSupply(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return fmt.Errorf("read total supply: %w", err)
+	}
+
+	fmt.Println("address: ", ts.address.Hex())
+	fmt.Println("name: ", name)
+	fmt.Println("symbol: ", symbol)
+	fmt.Println("decimals: ", ts.decimals)
+	fmt.Println("total supply: ", ts.amount(totalSupply))
+	return nil
+}
+
+func runSpeedup(cfg config, args []string) error {
+	return runReplace(cfg, "speedup", args)
+}
+
+func runCancel(cfg config, args []string) error {
+	return runReplace(cfg, "cancel", args)
+}
+
+// runReplace speeds up or cancels a pending transaction. With --every the
+// fees are bumped again each time that long passes without any of the
+// transactions at the nonce being mined.
+func runReplace(cfg config, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	bump := fs.Uint64("bump", erc20kit.ReplacementBump, "fee increase over the transaction being replaced, in percent")
+	every := fs.Duration("every", 0, "bump again after this long without a receipt, 0 to bump once")
+	tf := addTxFlags(fs, cfg)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s [flags] <txhash>", name)
+	}
+	hash, err := parseTxHash(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tx, err := erc20kit.PendingTransaction(ctx, client, hash)
+	if err != nil {
+		return err
+	}
+	sender, err := erc20kit.TxSender(tx)
+	if err != nil {
+		return fmt.Errorf("tx %s: %w", hash.Hex(), err)
+	}
+	account, err := resolveSigner(ctx, cfg, client, sender.Hex())
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(account); err != nil {
+		return err
+	}
+	replace := account.Speedup
+	if name == "cancel" {
+		replace = account.Cancel
+	}
+
+	if tf.wait.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tf.wait.Timeout)
+		defer cancel()
+	}
+	wait := tf.wait
+	wait.Timeout = *every
+
+	sent := []common.Hash{tx.Hash()}
+	for latest := tx; ; {
+		replacement, err := replace(ctx, latest, *bump)
+		if errors.Is(err, erc20kit.ErrNotPending) {
+			// one of the transactions sent so far made it in
+			fmt.Printf("nonce %d is already used\n", tx.Nonce())
+			wait.Timeout = 0
+		} else if err != nil {
+			return err
+		} else {
+			latest = replacement
+			sent = append(sent, replacement.Hash())
+			fmt.Printf("replacement %d sent: %s\n", len(sent)-1, replacement.Hash().Hex())
+		}
+
+		receipt, err := erc20kit.WaitForAnyReceipt(ctx, client, sent, wait)
+		if wait.Timeout > 0 && errors.Is(err, erc20kit.ErrTimeout) && ctx.Err() == nil {
+			continue
+		}
+		if receipt != nil {
+			reportMined(sent, receipt)
+		}
+		if err != nil {
+			return fmt.Errorf("nonce %d: %w", tx.Nonce(), err)
+		}
+		return nil
+	}
+}
+
+// reportMined prints which of the transactions sent at one nonce, the
+// original first, ended up in a block.
+func reportMined(sent []common.Hash, receipt *types.Receipt) {
+	label := "original"
+	for i, hash := range sent {
+		if i > 0 && hash == receipt.TxHash {
+			label = fmt.Sprintf("replacement %d", i)
+		}
+	}
+	fmt.Printf("%s %s mined in block %s, gas used %d\n", label, receipt.TxHash.Hex(), receipt.BlockNumber, receipt.GasUsed)
+}
+
+// runIndex copies the token's Transfer and Approval events into SQLite,
+// and with --follow keeps doing so until interrupted.
+func runIndex(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("index", cfg)
+	dbPath := fs.String("db", "token.db", "SQLite database to write")
+	fromBlock := fs.Uint64("from-block", 0, "first block on a fresh database, usually the token's deployment block")
+	chunk := fs.Uint64("chunk", index.DefaultChunkSize, "widest block range per log query")
+	confirmations := fs.Uint64("confirmations", 0, "stay this many blocks behind the head")
+	follow := fs.Bool("follow", false, "keep indexing new blocks until interrupted")
+	poll := fs.Duration("poll", index.DefaultPollInterval, "how often to look for new blocks with --follow")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	meta, err := resolveToken(ctx, cfg, client, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	store, err := index.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ix, err := index.New(store, meta.Address, client)
+	if err != nil {
+		return err
+	}
+	ix.ChunkSize = *chunk
+	ix.Confirmations = *confirmations
+	ix.PollInterval = *poll
+	ix.Progress = func(from, to uint64, events int) {
+		fmt.Printf("blocks %d-%d: %d events\n", from, to, events)
+	}
+	ix.Reorg = func(from, to uint64) {
+		fmt.Printf("reorg: rolled back from block %d to %d\n", from, to)
+	}
+	ix.OnError = func(err error) {
+		fmt.Fprintln(os.Stderr, "index:", err)
+	}
+
+	err = ix.Run(ctx, *fromBlock, *follow)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// runLedger prints balances and allowances derived from the index at a
+// block, and with --reconcile checks them against the token.
+func runLedger(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("ledger", cfg)
+	dbPath := fs.String("db", "token.db", "SQLite database written by index")
+	block := fs.Uint64("block", 0, "block to compute the ledger at, 0 for the last indexed one")
+	reconcile := fs.Bool("reconcile", false, "compare with totalSupply, balanceOf and allowance read at that block")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+	store, err := index.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *block == 0 {
+		cursor, ok, err := store.Cursor(ts.address)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("token %s is not indexed in %s", ts.address.Hex(), *dbPath)
+		}
+		*block = cursor.Number
+	}
+	ledger, err := store.LedgerAt(ts.address, *block)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ledger of %s at block %d\n", ts.address.Hex(), ledger.Block)
+	fmt.Printf("total supply: %s\n", ts.amount(ledger.TotalSupply))
+	fmt.Println("balances:")
+	for _, holder := range ledger.Holders() {
+		if balance := ledger.Balances[holder]; balance.Sign() != 0 {
+			fmt.Printf("  %s %s\n", holder.Hex(), ts.amount(balance))
+		}
+	}
+	fmt.Println("allowances:")
+	for _, key := range ledger.AllowanceKeys() {
+		if allowance := ledger.Allowances[key]; allowance.Sign() != 0 {
+			fmt.Printf("  %s -> %s %s\n", key.Owner.Hex(), key.Spender.Hex(), ts.amount(allowance))
+		}
+	}
+
+	if !*reconcile {
+		return nil
+	}
+	mismatches, err := ledger.Reconcile(ctx, ts.client)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		fmt.Println("reconcile: ledger matches the chain")
+		return nil
+	}
+	fmt.Println("reconcile: mismatches, in base units:")
+	for _, m := range mismatches {
+		fmt.Printf("  %s\n", m)
+	}
+	return fmt.Errorf("%d mismatches between ledger and chain at block %d", len(mismatches), ledger.Block)
+}
+
+// runWatch streams the token's events as JSON lines on stdout or POSTs
+// them to a webhook until interrupted. It needs a websocket or IPC
+// endpoint among the network profile's RPC URLs, which RPC_URL overrides.
+func runWatch(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("watch", cfg)
+	events := fs.String("events", "transfer,approval", "comma separated event kinds: transfer, approval")
+	from := fs.String("from", "", "only transfers from these aliases or addresses, comma separated")
+	to := fs.String("to", "", "only transfers to these aliases or addresses")
+	owner := fs.String("owner", "", "only approvals by these owners")
+	spender := fs.String("spender", "", "only approvals for these spenders")
+	webhook := fs.String("webhook", "", "POST every event as JSON to this URL instead of printing it")
+	retries := fs.Int("retries", 5, "webhook delivery retries")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+
+	var sink watch.Sink = watch.NewJSONLines(os.Stdout)
+	if *webhook != "" {
+		hook := watch.NewWebhook(*webhook)
+		hook.Retries = *retries
+		sink = hook
+	}
+	w, err := watch.New(ts.address, ts.decimals, ts.client, sink)
+	if err != nil {
+		return err
+	}
+	w.Transfers, w.Approvals = false, false
+	for _, kind := range strings.Split(*events, ",") {
+		switch strings.TrimSpace(kind) {
+		case watch.EventTransfer:
+			w.Transfers = true
+		case watch.EventApproval:
+			w.Approvals = true
+		default:
+			return fmt.Errorf("unknown event kind %q", kind)
+		}
+	}
+	for _, filter := range []struct {
+		list string
+		dst  *[]common.Address
+	}{{*from, &w.From}, {*to, &w.To}, {*owner, &w.Owner}, {*spender, &w.Spender}} {
+		if *filter.dst, err = resolveAddresses(cfg, filter.list); err != nil {
+			return err
+		}
+	}
+	w.OnError = func(err error) {
+		fmt.Fprintln(os.Stderr, "watch:", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "watching %s\n", ts.address.Hex())
+	err = w.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// runTokens manages the token registry of the connected chain.
+func runTokens(cfg config, args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	fs.Parse(args)
+	action, rest := "list", []string(nil)
+	if fs.NArg() > 0 {
+		action, rest = fs.Arg(0), fs.Args()[1:]
+	}
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", erc20kit.ErrChainID, err)
+	}
+	reg, err := registry.Load(cfg.TokenRegistry)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		for _, name := range reg.Names(chainID) {
+			meta, _ := reg.Lookup(chainID, name)
+			supply, _ := new(big.Int).SetString(meta.TotalSupply, 10)
+			fmt.Printf("%-8s %s %s (%s), %d decimals, supply %s as of %s\n", name, meta.Address.Hex(), meta.Name, meta.Symbol,
+				meta.Decimals, erc20kit.ToDecimal(supply, int(meta.Decimals)), meta.UpdatedAt.Format(time.RFC3339))
+		}
+		return nil
+
+	case "add":
+		if len(rest) != 2 || !common.IsHexAddress(rest[1]) {
+			return errors.New("usage: tokens add <name> <address>")
+		}
+		meta, err := registry.Fetch(ctx, client, common.HexToAddress(rest[1]))
+		if err != nil {
+			return err
+		}
+		reg.Put(chainID, rest[0], meta)
+		fmt.Printf("added %s: %s (%s), %d decimals\n", strings.ToUpper(rest[0]), meta.Name, meta.Symbol, meta.Decimals)
+		return reg.Save()
+
+	case "remove":
+		if len(rest) != 1 {
+			return errors.New("usage: tokens remove <name>")
+		}
+		if !reg.Remove(chainID, rest[0]) {
+			return fmt.Errorf("unknown token %q on chain %s", rest[0], chainID)
+		}
+		return reg.Save()
+
+	case "refresh":
+		names := rest
+		if len(names) == 0 {
+			names = reg.Names(chainID)
+		}
+		for _, name := range names {
+			cached, ok := reg.Lookup(chainID, name)
+			if !ok {
+				return fmt.Errorf("unknown token %q on chain %s", name, chainID)
+			}
+			meta, err := registry.Fetch(ctx, client, cached.Address)
+			if err != nil {
+				return err
+			}
+			reg.Put(chainID, name, meta)
+			fmt.Printf("refreshed %s\n", strings.ToUpper(name))
+		}
+		return reg.Save()
+
+	default:
+		return fmt.Errorf("unknown tokens action %q", action)
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: MIT
+// Built with `solc --optimize --evm-version london --abi --bin` and bound with
+// `abigen --abi ERC20.abi --bin ERC20.bin --pkg ERC20 --type ERC20 --out ERC20.go`.
+
+pragma solidity ^0.8.0;
+
+import {ERC20 as ERC20Base} from "../openzeppelin/token/ERC20/ERC20.sol";
+import "../openzeppelin/access/Ownable.sol";
+
+/**
+ * @dev OpenZeppelin ERC20 with configurable decimals and an owner-only mint.
+ * The whole initial supply goes to the deployer, who also becomes the owner.
+ */
+contract ERC20 is ERC20Base, Ownable {
+    uint8 private _decimals;
+
+    constructor(
+        string memory name_,
+        string memory symbol_,
+        uint8 decimals_,
+        uint256 initialSupply
+    ) ERC20Base(name_, symbol_) {
+        _decimals = decimals_;
+        _mint(_msgSender(), initialSupply);
+    }
+
+    /**
+     * @dev See {IERC20Metadata-decimals}.
+     */
+    function decimals() public view virtual override returns (uint8) {
+        return _decimals;
+    }
+
+    /**
+     * @dev Creates `amount` new tokens for `to`. Only the owner may mint.
+     */
+    function mint(address to, uint256 amount) public virtual onlyOwner returns (bool) {
+        _mint(to, amount);
+        return true;
+    }
+}
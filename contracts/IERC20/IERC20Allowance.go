@@ -0,0 +1,222 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ERC20token
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// IERC20AllowanceMetaData contains all meta data concerning the IERC20Allowance contract.
+var IERC20AllowanceMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"subtractedValue\",\"type\":\"uint256\"}],\"name\":\"decreaseAllowance\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"addedValue\",\"type\":\"uint256\"}],\"name\":\"increaseAllowance\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+}
+
+// IERC20AllowanceABI is the input ABI used to generate the binding from.
+// Deprecated: Use IERC20AllowanceMetaData.ABI instead.
+var IERC20AllowanceABI = IERC20AllowanceMetaData.ABI
+
+// IERC20Allowance is an auto generated Go binding around an Ethereum contract.
+type IERC20Allowance struct {
+	IERC20AllowanceCaller     // Read-only binding to the contract
+	IERC20AllowanceTransactor // Write-only binding to the contract
+	IERC20AllowanceFilterer   // Log filterer for contract events
+}
+
+// IERC20AllowanceCaller is an auto generated read-only Go binding around an Ethereum contract.
+type IERC20AllowanceCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IERC20AllowanceTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type IERC20AllowanceTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IERC20AllowanceFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type IERC20AllowanceFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// IERC20AllowanceSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type IERC20AllowanceSession struct {
+	Contract     *IERC20Allowance  // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// IERC20AllowanceCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type IERC20AllowanceCallerSession struct {
+	Contract *IERC20AllowanceCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts          // Call options to use throughout this session
+}
+
+// IERC20AllowanceTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type IERC20AllowanceTransactorSession struct {
+	Contract     *IERC20AllowanceTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts          // Transaction auth options to use throughout this session
+}
+
+// IERC20AllowanceRaw is an auto generated low-level Go binding around an Ethereum contract.
+type IERC20AllowanceRaw struct {
+	Contract *IERC20Allowance // Generic contract binding to access the raw methods on
+}
+
+// IERC20AllowanceCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type IERC20AllowanceCallerRaw struct {
+	Contract *IERC20AllowanceCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// IERC20AllowanceTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type IERC20AllowanceTransactorRaw struct {
+	Contract *IERC20AllowanceTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewIERC20Allowance creates a new instance of IERC20Allowance, bound to a specific deployed contract.
+func NewIERC20Allowance(address common.Address, backend bind.ContractBackend) (*IERC20Allowance, error) {
+	contract, err := bindIERC20Allowance(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &IERC20Allowance{IERC20AllowanceCaller: IERC20AllowanceCaller{contract: contract}, IERC20AllowanceTransactor: IERC20AllowanceTransactor{contract: contract}, IERC20AllowanceFilterer: IERC20AllowanceFilterer{contract: contract}}, nil
+}
+
+// NewIERC20AllowanceCaller creates a new read-only instance of IERC20Allowance, bound to a specific deployed contract.
+func NewIERC20AllowanceCaller(address common.Address, caller bind.ContractCaller) (*IERC20AllowanceCaller, error) {
+	contract, err := bindIERC20Allowance(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IERC20AllowanceCaller{contract: contract}, nil
+}
+
+// NewIERC20AllowanceTransactor creates a new write-only instance of IERC20Allowance, bound to a specific deployed contract.
+func NewIERC20AllowanceTransactor(address common.Address, transactor bind.ContractTransactor) (*IERC20AllowanceTransactor, error) {
+	contract, err := bindIERC20Allowance(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &IERC20AllowanceTransactor{contract: contract}, nil
+}
+
+// NewIERC20AllowanceFilterer creates a new log filterer instance of IERC20Allowance, bound to a specific deployed contract.
+func NewIERC20AllowanceFilterer(address common.Address, filterer bind.ContractFilterer) (*IERC20AllowanceFilterer, error) {
+	contract, err := bindIERC20Allowance(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &IERC20AllowanceFilterer{contract: contract}, nil
+}
+
+// bindIERC20Allowance binds a generic wrapper to an already deployed contract.
+func bindIERC20Allowance(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(IERC20AllowanceABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IERC20Allowance *IERC20AllowanceRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IERC20Allowance.Contract.IERC20AllowanceCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IERC20Allowance *IERC20AllowanceRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.IERC20AllowanceTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IERC20Allowance *IERC20AllowanceRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.IERC20AllowanceTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_IERC20Allowance *IERC20AllowanceCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _IERC20Allowance.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_IERC20Allowance *IERC20AllowanceTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_IERC20Allowance *IERC20AllowanceTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.contract.Transact(opts, method, params...)
+}
+
+// DecreaseAllowance is a paid mutator transaction binding the contract method 0xa457c2d7.
+//
+// Solidity: function decreaseAllowance(address spender, uint256 subtractedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceTransactor) DecreaseAllowance(opts *bind.TransactOpts, spender common.Address, subtractedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.contract.Transact(opts, "decreaseAllowance", spender, subtractedValue)
+}
+
+// DecreaseAllowance is a paid mutator transaction binding the contract method 0xa457c2d7.
+//
+// Solidity: function decreaseAllowance(address spender, uint256 subtractedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceSession) DecreaseAllowance(spender common.Address, subtractedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.DecreaseAllowance(&_IERC20Allowance.TransactOpts, spender, subtractedValue)
+}
+
+// DecreaseAllowance is a paid mutator transaction binding the contract method 0xa457c2d7.
+//
+// Solidity: function decreaseAllowance(address spender, uint256 subtractedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceTransactorSession) DecreaseAllowance(spender common.Address, subtractedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.DecreaseAllowance(&_IERC20Allowance.TransactOpts, spender, subtractedValue)
+}
+
+// IncreaseAllowance is a paid mutator transaction binding the contract method 0x39509351.
+//
+// Solidity: function increaseAllowance(address spender, uint256 addedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceTransactor) IncreaseAllowance(opts *bind.TransactOpts, spender common.Address, addedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.contract.Transact(opts, "increaseAllowance", spender, addedValue)
+}
+
+// IncreaseAllowance is a paid mutator transaction binding the contract method 0x39509351.
+//
+// Solidity: function increaseAllowance(address spender, uint256 addedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceSession) IncreaseAllowance(spender common.Address, addedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.IncreaseAllowance(&_IERC20Allowance.TransactOpts, spender, addedValue)
+}
+
+// IncreaseAllowance is a paid mutator transaction binding the contract method 0x39509351.
+//
+// Solidity: function increaseAllowance(address spender, uint256 addedValue) returns(bool)
+func (_IERC20Allowance *IERC20AllowanceTransactorSession) IncreaseAllowance(spender common.Address, addedValue *big.Int) (*types.Transaction, error) {
+	return _IERC20Allowance.Contract.IncreaseAllowance(&_IERC20Allowance.TransactOpts, spender, addedValue)
+}
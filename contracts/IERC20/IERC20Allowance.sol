@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: MIT
+
+pragma solidity ^0.8.0;
+
+/**
+ * @dev The allowance helpers of OpenZeppelin's ERC20, which are not part of
+ * the standard. They change an allowance relative to its current value, so
+ * they do not suffer from the {IERC20-approve} ordering issue.
+ */
+interface IERC20Allowance {
+    /**
+     * @dev Atomically increases the allowance granted to `spender` by the caller.
+     *
+     * Emits an {Approval} event indicating the updated allowance.
+     */
+    function increaseAllowance(address spender, uint256 addedValue) external returns (bool);
+
+    /**
+     * @dev Atomically decreases the allowance granted to `spender` by the caller.
+     *
+     * Emits an {Approval} event indicating the updated allowance.
+     *
+     * Requirements:
+     *
+     * - `spender` must have allowance for the caller of at least
+     * `subtractedValue`.
+     */
+    function decreaseAllowance(address spender, uint256 subtractedValue) external returns (bool);
+}
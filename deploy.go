@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/registry"
+)
+
+// runDeploy deploys the mintable ERC20 of contracts/ERC20 and waits for
+// its address. With --register the token can then be passed to --token
+// by name.
+func runDeploy(cfg config, args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	from := fs.String("from", "deployer", "deploying account alias, which owns the token and receives the supply")
+	name := fs.String("name", "", "token name")
+	symbol := fs.String("symbol", "", "token symbol")
+	decimals := fs.Uint("decimals", 18, "token decimals")
+	supplyStr := fs.String("supply", "0", "initial supply in whole tokens")
+	register := fs.String("register", "", "add the token to the registry under this name")
+	tf := addTxFlags(fs, cfg)
+	tf.addExportFlag(fs)
+	fs.Parse(args)
+
+	if *name == "" || *symbol == "" {
+		return errors.New("usage: deploy --name <name> --symbol <symbol> [--decimals 18] [--supply N]")
+	}
+	if *decimals > 255 {
+		return fmt.Errorf("--decimals must fit in uint8, got %d", *decimals)
+	}
+	supply, err := erc20kit.ToWeiExact(*supplyStr, int(*decimals))
+	if err != nil {
+		return fmt.Errorf("invalid --supply %q: %w", *supplyStr, err)
+	}
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	description := fmt.Sprintf("deploy %s (%s), %d decimals, supply %s", *name, *symbol, *decimals, erc20kit.ToDecimal(supply, int(*decimals)))
+
+	var address common.Address
+	if tf.export != "" {
+		return exportTx(ctx, cfg, client, tf, *from, description, erc20kit.DeployERC20(client, *name, *symbol, uint8(*decimals), supply, &address))
+	}
+	deployer, err := resolveSigner(ctx, cfg, client, *from)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(deployer); err != nil {
+		return err
+	}
+	if tf.dryRun {
+		return previewTx(ctx, cfg, client, deployer, description, erc20kit.DeployERC20(client, *name, *symbol, uint8(*decimals), supply, &address))
+	}
+
+	fmt.Println(description)
+	address, tx, err := deployer.DeployERC20(ctx, *name, *symbol, uint8(*decimals), supply)
+	if err != nil {
+		return err
+	}
+	if err := waitForTx(ctx, client, tx, tf); err != nil {
+		return err
+	}
+	// read back through the token interface, as every other command will
+	meta, err := registry.Fetch(ctx, client, address)
+	if err != nil {
+		return fmt.Errorf("deployed %s: %w", address.Hex(), err)
+	}
+	fmt.Printf("%-12s %s\n", "token:", address.Hex())
+
+	if *register == "" {
+		fmt.Printf("pass --token %s or set TOKEN_ADDRESS to use it\n", address.Hex())
+		return nil
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", erc20kit.ErrChainID, err)
+	}
+	reg, err := registry.Load(cfg.TokenRegistry)
+	if err != nil {
+		return err
+	}
+	reg.Put(chainID, *register, meta)
+	if err := reg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("registered as %s, pass --token %s to use it\n", strings.ToUpper(*register), *register)
+	return nil
+}
@@ -0,0 +1,73 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Account is a signer with transact options for one chain. Auth leaves
+// nonce, fees and gas limit to the binding; Send instead takes the nonce
+// from Nonces, the fees from Gas and adds GasLimitMargin to the estimated
+// limit.
+type Account struct {
+	Signer  Signer
+	Address common.Address
+	Auth    *bind.TransactOpts
+	Nonces  *NonceManager
+
+	// Gas is asked for fees before every transaction sent with Send.
+	Gas GasStrategy
+	// GasLimitMargin is the percentage added to EstimateGas when Auth
+	// has no fixed GasLimit.
+	GasLimitMargin uint64
+	// LowFunds, when set, is called before a transaction sent with Send
+	// is signed if the account's balance cannot cover its MaxCost. It is
+	// a warning only: the transaction is still signed and sent.
+	LowFunds func(tx *types.Transaction, maxCost, balance *big.Int)
+
+	backend Backend
+	chainID *big.Int
+}
+
+// NewAccount prepares transact options that sign with signer for the
+// chain the backend is connected to.
+func NewAccount(ctx context.Context, backend Backend, signer Signer) (*Account, error) {
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, wrap(ErrChainID, err)
+	}
+
+	address := signer.Address()
+	account := &Account{
+		Signer:         signer,
+		Address:        address,
+		Nonces:         NewNonceManager(backend, address),
+		Gas:            AutoGas{},
+		GasLimitMargin: DefaultGasLimitMargin,
+		backend:        backend,
+		chainID:        chainID,
+	}
+	account.Auth = &bind.TransactOpts{
+		From:     address,
+		Signer:   account.signerFn(context.Background()),
+		Context:  context.Background(),
+		Value:    big.NewInt(0), // in wei
+		GasLimit: 0,             // estimated per transaction
+	}
+	return account, nil
+}
+
+// signerFn signs the account's transactions for its chain, with ctx bounding
+// a request to an external signer.
+func (a *Account) signerFn(ctx context.Context) bind.SignerFn {
+	return func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if from != a.Address {
+			return nil, bind.ErrNotAuthorized
+		}
+		return a.Signer.SignTx(ctx, tx, a.chainID)
+	}
+}
@@ -0,0 +1,57 @@
+package erc20kit_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+func TestReferenceToken(t *testing.T) {
+	chain := newTestChain(t)
+	opts := &bind.CallOpts{Context: chain.ctx}
+
+	if name, err := chain.Instance.Name(opts); err != nil || name != simulated.TokenName {
+		t.Errorf("name = %q, %v; want %q", name, err, simulated.TokenName)
+	}
+	if symbol, err := chain.Instance.Symbol(opts); err != nil || symbol != simulated.TokenSymbol {
+		t.Errorf("symbol = %q, %v; want %q", symbol, err, simulated.TokenSymbol)
+	}
+	if decimals, err := chain.Instance.Decimals(opts); err != nil || decimals != simulated.TokenDecimals {
+		t.Errorf("decimals = %d, %v; want %d", decimals, err, simulated.TokenDecimals)
+	}
+	if totalSupply, err := chain.Instance.TotalSupply(opts); err != nil || totalSupply.Cmp(simulated.InitialSupply) != 0 {
+		t.Errorf("total supply = %v, %v; want %v", totalSupply, err, simulated.InitialSupply)
+	}
+	chain.expectBalance("deployer", chain.Deployer.Address, simulated.InitialSupply)
+	chain.expectBalance("user", chain.User.Address, big.NewInt(0))
+}
+
+// TestTransferFlow replays the transfer, approve and transferFrom sequence
+// of the commands and checks every balance along the way.
+func TestTransferFlow(t *testing.T) {
+	chain := newTestChain(t)
+	supply := simulated.InitialSupply
+
+	amount := wei("100")
+	chain.mined("transfer", func() (*types.Transaction, error) {
+		return chain.Instance.Transfer(chain.Deployer.Auth, chain.User.Address, amount)
+	})
+	chain.expectBalance("deployer", chain.Deployer.Address, new(big.Int).Sub(supply, amount))
+	chain.expectBalance("user", chain.User.Address, amount)
+
+	chain.mined("approve", func() (*types.Transaction, error) {
+		return chain.Instance.Approve(chain.User.Auth, chain.Deployer.Address, amount)
+	})
+	chain.expectAllowance(chain.User.Address, chain.Deployer.Address, amount)
+
+	chain.mined("transferFrom", func() (*types.Transaction, error) {
+		return chain.Instance.TransferFrom(chain.Deployer.Auth, chain.User.Address, chain.Deployer.Address, wei("10"))
+	})
+	chain.expectBalance("deployer", chain.Deployer.Address, new(big.Int).Sub(supply, wei("90")))
+	chain.expectBalance("user", chain.User.Address, wei("90"))
+	chain.expectAllowance(chain.User.Address, chain.Deployer.Address, wei("90"))
+}
@@ -0,0 +1,227 @@
+package erc20kit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+)
+
+// Allowances changes the allowances an account gives on one token without
+// the approve ordering race: changing a non-zero allowance with approve
+// lets the spender use both the old and the new one if it gets in between.
+// Relative changes with increaseAllowance/decreaseAllowance are used when
+// the token has them; otherwise the allowance is reset to zero first.
+type Allowances struct {
+	Account *Account
+	Token   common.Address
+	// Wait is used for the reset to zero, which must be mined before the
+	// new allowance can be estimated on tokens that insist on it.
+	Wait WaitOptions
+
+	erc20    *token.ERC20token
+	relative *token.IERC20Allowance
+}
+
+// NewAllowances manages the allowances account gives on tokenAddress.
+func NewAllowances(account *Account, tokenAddress common.Address) (*Allowances, error) {
+	erc20, err := token.NewERC20token(tokenAddress, account.backend)
+	if err != nil {
+		return nil, err
+	}
+	relative, err := token.NewIERC20Allowance(tokenAddress, account.backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Allowances{Account: account, Token: tokenAddress, erc20: erc20, relative: relative}, nil
+}
+
+// Allowance returns what spender may currently spend.
+func (m *Allowances) Allowance(ctx context.Context, spender common.Address) (*big.Int, error) {
+	return m.erc20.Allowance(&bind.CallOpts{Context: ctx}, m.Account.Address, spender)
+}
+
+// SupportsRelative reports whether the token has increaseAllowance and
+// decreaseAllowance, by calling increaseAllowance(spender, 0) as the
+// account. A token without them reverts or returns nothing.
+func (m *Allowances) SupportsRelative(ctx context.Context, spender common.Address) bool {
+	var out []interface{}
+	raw := &token.IERC20AllowanceRaw{Contract: m.relative}
+	opts := &bind.CallOpts{From: m.Account.Address, Context: ctx}
+	if err := raw.Call(opts, &out, "increaseAllowance", spender, big.NewInt(0)); err != nil || len(out) != 1 {
+		return false
+	}
+	ok, _ := out[0].(bool)
+	return ok
+}
+
+// Set makes the allowance of spender exactly amount and returns the
+// transactions it sent, in order. Only the last one may still be pending;
+// none are sent when the allowance already is amount.
+func (m *Allowances) Set(ctx context.Context, spender common.Address, amount *big.Int) ([]*types.Transaction, error) {
+	current, err := m.Allowance(ctx, spender)
+	if err != nil {
+		return nil, err
+	}
+	delta := new(big.Int).Sub(amount, current)
+	switch {
+	case delta.Sign() == 0:
+		return nil, nil
+	case !m.SupportsRelative(ctx, spender):
+		return m.approve(ctx, spender, current, amount)
+	case delta.Sign() > 0:
+		return m.send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.relative.IncreaseAllowance(opts, spender, delta)
+		})
+	default:
+		return m.send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.relative.DecreaseAllowance(opts, spender, new(big.Int).Neg(delta))
+		})
+	}
+}
+
+// Increase raises the allowance of spender by delta.
+func (m *Allowances) Increase(ctx context.Context, spender common.Address, delta *big.Int) ([]*types.Transaction, error) {
+	if m.SupportsRelative(ctx, spender) {
+		return m.send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.relative.IncreaseAllowance(opts, spender, delta)
+		})
+	}
+	current, err := m.Allowance(ctx, spender)
+	if err != nil {
+		return nil, err
+	}
+	return m.approve(ctx, spender, current, new(big.Int).Add(current, delta))
+}
+
+// Decrease lowers the allowance of spender by delta, which may not exceed
+// it.
+func (m *Allowances) Decrease(ctx context.Context, spender common.Address, delta *big.Int) ([]*types.Transaction, error) {
+	current, err := m.Allowance(ctx, spender)
+	if err != nil {
+		return nil, err
+	}
+	if current.Cmp(delta) < 0 {
+		return nil, fmt.Errorf("%w: allowance %s is below %s", ErrInvalidAmount, current, delta)
+	}
+	if m.SupportsRelative(ctx, spender) {
+		return m.send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.relative.DecreaseAllowance(opts, spender, delta)
+		})
+	}
+	return m.approve(ctx, spender, current, new(big.Int).Sub(current, delta))
+}
+
+// Revoke sets the allowance of each spender to zero. approve(0) needs no
+// reset, so the transactions go out back to back.
+func (m *Allowances) Revoke(ctx context.Context, spenders []common.Address) ([]*types.Transaction, error) {
+	var sent []*types.Transaction
+	for _, spender := range spenders {
+		tx, err := m.Account.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.erc20.Approve(opts, spender, big.NewInt(0))
+		})
+		if err != nil {
+			return sent, fmt.Errorf("revoke %s: %w", spender.Hex(), err)
+		}
+		sent = append(sent, tx)
+	}
+	return sent, nil
+}
+
+// approve goes from current to amount with plain approve calls, through
+// zero when both are non-zero.
+func (m *Allowances) approve(ctx context.Context, spender common.Address, current, amount *big.Int) ([]*types.Transaction, error) {
+	var sent []*types.Transaction
+	if current.Sign() > 0 && amount.Sign() > 0 {
+		reset, err := m.Account.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return m.erc20.Approve(opts, spender, big.NewInt(0))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reset allowance: %w", err)
+		}
+		sent = append(sent, reset)
+		if _, err := WaitForReceipt(ctx, m.Account.backend, reset.Hash(), m.Wait); err != nil {
+			return sent, fmt.Errorf("reset allowance: %w", err)
+		}
+	}
+	tx, err := m.Account.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return m.erc20.Approve(opts, spender, amount)
+	})
+	if err != nil {
+		return sent, err
+	}
+	return append(sent, tx), nil
+}
+
+func (m *Allowances) send(ctx context.Context, send func(opts *bind.TransactOpts) (*types.Transaction, error)) ([]*types.Transaction, error) {
+	tx, err := m.Account.Send(ctx, send)
+	if err != nil {
+		return nil, err
+	}
+	return []*types.Transaction{tx}, nil
+}
+
+// Approval is a spender an owner has approved, with its allowance now.
+type Approval struct {
+	Spender   common.Address
+	Allowance *big.Int
+	// Block is the last block the owner approved the spender in.
+	Block uint64
+}
+
+// Approvals finds every spender owner has approved on a token since block
+// from, from the token's Approval logs, and reads its current allowance.
+// A log range the node refuses as too wide is halved until it goes
+// through.
+func Approvals(ctx context.Context, backend Backend, tokenAddress, owner common.Address, from uint64) ([]Approval, error) {
+	erc20, err := token.NewERC20token(tokenAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, wrap(ErrHeader, err)
+	}
+
+	last := make(map[common.Address]uint64)
+	err = WalkLogRanges(ctx, from, head.Number.Uint64(), DefaultLogChunk, func(from, to uint64) error {
+		it, err := erc20.FilterApproval(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, []common.Address{owner}, nil)
+		if err != nil {
+			return fmt.Errorf("approval logs %d-%d: %w", from, to, err)
+		}
+		defer it.Close()
+		for it.Next() {
+			last[it.Event.Spender] = it.Event.Raw.BlockNumber
+		}
+		if err := it.Error(); err != nil {
+			return fmt.Errorf("approval logs %d-%d: %w", from, to, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := make([]Approval, 0, len(last))
+	for spender, block := range last {
+		allowance, err := erc20.Allowance(&bind.CallOpts{Context: ctx}, owner, spender)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, Approval{Spender: spender, Allowance: allowance, Block: block})
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		if approvals[i].Block != approvals[j].Block {
+			return approvals[i].Block < approvals[j].Block
+		}
+		return bytes.Compare(approvals[i].Spender[:], approvals[j].Spender[:]) < 0
+	})
+	return approvals, nil
+}
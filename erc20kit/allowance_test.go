@@ -0,0 +1,85 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// TestAllowances changes allowances relative to their value, lists them
+// back from the Approval logs and revokes them all.
+func TestAllowances(t *testing.T) {
+	chain := newTestChain(t)
+	chain.mined("approve", func() (*types.Transaction, error) {
+		return chain.Instance.Approve(chain.User.Auth, chain.Deployer.Address, wei("1"))
+	})
+	allowances, err := erc20kit.NewAllowances(chain.User, chain.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := common.HexToAddress("0xa1"), common.HexToAddress("0xa2")
+	if !allowances.SupportsRelative(chain.ctx, first) {
+		t.Fatal("reference token: increaseAllowance not detected")
+	}
+	change := func(step string, spender common.Address, send func() ([]*types.Transaction, error), want *big.Int) {
+		t.Helper()
+		chain.mined(step, func() (*types.Transaction, error) {
+			sent, err := send()
+			if err == nil && len(sent) != 1 {
+				err = fmt.Errorf("sent %d transactions, want 1", len(sent))
+			}
+			if err != nil {
+				return nil, err
+			}
+			return sent[0], nil
+		})
+		chain.expectAllowance(chain.User.Address, spender, want)
+	}
+	change("set allowance", first, func() ([]*types.Transaction, error) {
+		return allowances.Set(chain.ctx, first, wei("5"))
+	}, wei("5"))
+	change("set lower allowance", first, func() ([]*types.Transaction, error) {
+		return allowances.Set(chain.ctx, first, wei("3"))
+	}, wei("3"))
+	change("increase allowance", first, func() ([]*types.Transaction, error) {
+		return allowances.Increase(chain.ctx, first, wei("2"))
+	}, wei("5"))
+	change("set second allowance", second, func() ([]*types.Transaction, error) {
+		return allowances.Set(chain.ctx, second, wei("1"))
+	}, wei("1"))
+	if sent, err := allowances.Set(chain.ctx, first, wei("5")); err != nil || len(sent) != 0 {
+		t.Fatalf("set unchanged allowance: sent %d transactions (%v)", len(sent), err)
+	}
+	if _, err := allowances.Decrease(chain.ctx, first, wei("10")); !errors.Is(err, erc20kit.ErrInvalidAmount) {
+		t.Fatalf("decrease below zero: want ErrInvalidAmount, got %v", err)
+	}
+
+	approvals, err := erc20kit.Approvals(chain.ctx, chain.Backend, chain.Token, chain.User.Address, 0)
+	if err != nil {
+		t.Fatalf("list approvals: %v", err)
+	}
+	found := make(map[common.Address]*big.Int)
+	for _, a := range approvals {
+		found[a.Spender] = a.Allowance
+	}
+	if found[first] == nil || found[first].Cmp(wei("5")) != 0 || found[second] == nil || found[second].Cmp(wei("1")) != 0 || found[chain.Deployer.Address] == nil {
+		t.Fatalf("approvals: %v", found)
+	}
+
+	sent, err := allowances.Revoke(chain.ctx, []common.Address{first, second})
+	if err != nil || len(sent) != 2 {
+		t.Fatalf("revoke: sent %d transactions (%v)", len(sent), err)
+	}
+	for _, tx := range sent {
+		tx := tx
+		chain.mined("revoke", func() (*types.Transaction, error) { return tx, nil })
+	}
+	chain.expectAllowance(chain.User.Address, first, big.NewInt(0))
+	chain.expectAllowance(chain.User.Address, second, big.NewInt(0))
+}
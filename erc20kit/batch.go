@@ -0,0 +1,305 @@
+package erc20kit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Payment is one row of a batch transfer.
+type Payment struct {
+	// Row is the 1-based line of a CSV file or index of a JSON array.
+	Row       int
+	Recipient common.Address
+	Amount    *big.Int
+	// Key identifies the payment in a Journal. It is built from the
+	// recipient, the amount and how many identical rows came before, so
+	// reordering or inserting rows does not make a paid row look new.
+	Key string
+}
+
+// ReadPayments parses recipient,amount rows. format is "csv" or "json";
+// CSV may start with a header row and JSON is an array of
+// {"recipient": "0x...", "amount": "1.5"} objects. Amounts are in whole
+// tokens. Every invalid row is reported, not just the first.
+func ReadPayments(r io.Reader, format string, decimals int) ([]Payment, error) {
+	var (
+		rows []paymentRow
+		err  error
+	)
+	switch format {
+	case "csv":
+		rows, err = readCSVRows(r)
+	case "json":
+		rows, err = readJSONRows(r)
+	default:
+		return nil, fmt.Errorf("unknown payments format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		payments []Payment
+		invalid  []string
+		seen     = make(map[string]int)
+	)
+	for _, row := range rows {
+		payment, err := row.payment(decimals)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("row %d: %v", row.n, err))
+			continue
+		}
+		id := payment.Recipient.Hex() + ":" + payment.Amount.String()
+		payment.Key = fmt.Sprintf("%s:%d", id, seen[id])
+		seen[id]++
+		payments = append(payments, payment)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%d invalid rows:\n%s", len(invalid), strings.Join(invalid, "\n"))
+	}
+	if len(payments) == 0 {
+		return nil, errors.New("no payments found")
+	}
+	return payments, nil
+}
+
+type paymentRow struct {
+	n                 int
+	recipient, amount string
+}
+
+func (row paymentRow) payment(decimals int) (Payment, error) {
+	if !common.IsHexAddress(row.recipient) {
+		return Payment{}, fmt.Errorf("invalid recipient %q", row.recipient)
+	}
+	recipient := common.HexToAddress(row.recipient)
+	if recipient == (common.Address{}) {
+		return Payment{}, errors.New("recipient is the zero address")
+	}
+	amount, err := ToWeiExact(row.amount, decimals)
+	if err != nil {
+		return Payment{}, fmt.Errorf("amount %q: %w", row.amount, err)
+	}
+	if amount.Sign() == 0 {
+		return Payment{}, errors.New("amount is zero")
+	}
+	return Payment{Row: row.n, Recipient: recipient, Amount: amount}, nil
+}
+
+func readCSVRows(r io.Reader) ([]paymentRow, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []paymentRow
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		// a first row that does not start with an address is a header such
+		// as "recipient,amount"
+		if first && !common.IsHexAddress(strings.TrimSpace(record[0])) && !strings.HasPrefix(record[0], "0x") {
+			continue
+		}
+		if len(record) != 2 {
+			return nil, fmt.Errorf("row %d: want recipient,amount, got %d fields", line, len(record))
+		}
+		rows = append(rows, paymentRow{n: line, recipient: strings.TrimSpace(record[0]), amount: strings.TrimSpace(record[1])})
+	}
+}
+
+func readJSONRows(r io.Reader) ([]paymentRow, error) {
+	var records []struct {
+		Recipient string      `json:"recipient"`
+		Amount    json.Number `json:"amount"`
+	}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	rows := make([]paymentRow, len(records))
+	for i, record := range records {
+		rows[i] = paymentRow{n: i + 1, recipient: record.Recipient, amount: record.Amount.String()}
+	}
+	return rows, nil
+}
+
+// Journal entry statuses.
+const (
+	// StatusSent is recorded after signing and before broadcasting, so a
+	// crash in between leaves the raw transaction to rebroadcast.
+	StatusSent = "sent"
+	// StatusSendFailed means the transaction never reached the node and
+	// the payment can be retried.
+	StatusSendFailed = "send-failed"
+	StatusMined      = "mined"
+	// StatusReverted means the transfer was mined but failed, so no tokens
+	// moved and the payment can be retried.
+	StatusReverted = "reverted"
+	// StatusUnknown means the transaction is gone and its nonce was used by
+	// something else. It is never retried automatically.
+	StatusUnknown = "unknown"
+)
+
+// IsRejected reports whether err from SendTransaction means the node turned
+// the transaction down, so it is not in any pool and cannot be mined.
+func IsRejected(err error) bool {
+	if isNonceTooLow(err) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, reason := range []string{
+		"insufficient funds",
+		"intrinsic gas too low",
+		"exceeds block gas limit",
+		"transaction underpriced",
+		"fee per gas",
+		"invalid sender",
+		"oversized data",
+		"negative value",
+	} {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// JournalEntry is the latest known state of one payment.
+type JournalEntry struct {
+	Key       string `json:"key"`
+	Token     string `json:"token"`
+	From      string `json:"from"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Status    string `json:"status"`
+	Nonce     uint64 `json:"nonce,omitempty"`
+	TxHash    string `json:"tx,omitempty"`
+	RawTx     string `json:"raw,omitempty"`
+	Block     uint64 `json:"block,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Journal is an append-only JSON lines file of payment states. Later lines
+// for a key supersede earlier ones, and every record is synced to disk
+// before Record returns.
+type Journal struct {
+	f       *os.File
+	entries map[string]JournalEntry
+}
+
+// OpenJournal loads the journal at path, creating it if needed.
+func OpenJournal(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{f: f, entries: make(map[string]JournalEntry)}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Key == "" {
+			// a line torn by a crash mid-write; Record had not returned,
+			// so nothing was broadcast on the strength of it
+			continue
+		}
+		j.entries[entry.Key] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("journal %s: %w", path, err)
+	}
+	if err := terminateLastLine(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// terminateLastLine makes sure the next record starts on its own line
+// after a torn write.
+func terminateLastLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = f.Write([]byte{'\n'})
+	return err
+}
+
+// Entry returns the latest entry for key.
+func (j *Journal) Entry(key string) (JournalEntry, bool) {
+	entry, ok := j.entries[key]
+	return entry, ok
+}
+
+// Sent returns the entries of payments whose transaction was signed and
+// possibly broadcast but not settled, in nonce order. They must be settled
+// or rebroadcast, never sent anew.
+func (j *Journal) Sent(payments []Payment) []JournalEntry {
+	var sent []JournalEntry
+	for _, p := range payments {
+		if entry, ok := j.entries[p.Key]; ok && entry.Status == StatusSent {
+			sent = append(sent, entry)
+		}
+	}
+	sort.Slice(sent, func(i, k int) bool { return sent[i].Nonce < sent[k].Nonce })
+	return sent
+}
+
+// Unpaid returns the payments that have no transaction that could still
+// pay them: never sent, not sent by the node or reverted.
+func (j *Journal) Unpaid(payments []Payment) []Payment {
+	var todo []Payment
+	for _, p := range payments {
+		entry, ok := j.entries[p.Key]
+		if !ok || entry.Status == StatusSendFailed || entry.Status == StatusReverted {
+			todo = append(todo, p)
+		}
+	}
+	return todo
+}
+
+// Record appends entry and syncs the file.
+func (j *Journal) Record(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := j.f.Sync(); err != nil {
+		return err
+	}
+	j.entries[entry.Key] = entry
+	return nil
+}
+
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
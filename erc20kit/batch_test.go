@@ -0,0 +1,183 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"gb-sc-homework/erc20kit"
+)
+
+const (
+	alice = "0x00000000000000000000000000000000000A11cE"
+	bob   = "0x0000000000000000000000000000000000000B0b"
+)
+
+func TestReadPayments(t *testing.T) {
+	csv := "recipient,amount\n" +
+		"# paid in June\n" +
+		alice + ", 1.5\n" +
+		bob + ",2\n" +
+		alice + ",1.5\n"
+	json := `[{"recipient": "` + alice + `", "amount": "1.5"}, {"recipient": "` + bob + `", "amount": 2}, {"recipient": "` + alice + `", "amount": "1.5"}]`
+	for format, input := range map[string]string{"csv": csv, "json": json} {
+		payments, err := erc20kit.ReadPayments(strings.NewReader(input), format, 2)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(payments) != 3 {
+			t.Fatalf("%s: %d payments, want 3", format, len(payments))
+		}
+		if payments[0].Recipient != common.HexToAddress(alice) || payments[0].Amount.Int64() != 150 || payments[1].Amount.Int64() != 200 {
+			t.Errorf("%s: first payments %+v, %+v", format, payments[0], payments[1])
+		}
+		// identical rows are told apart by their position among themselves
+		if payments[0].Key == payments[2].Key || payments[0].Key == payments[1].Key {
+			t.Errorf("%s: keys %q, %q, %q are not distinct", format, payments[0].Key, payments[1].Key, payments[2].Key)
+		}
+	}
+
+	// inserting a row before a paid one does not change the paid one's key
+	before, _ := erc20kit.ReadPayments(strings.NewReader(alice+",1\n"), "csv", 2)
+	after, _ := erc20kit.ReadPayments(strings.NewReader(bob+",3\n"+alice+",1\n"), "csv", 2)
+	if before[0].Key != after[1].Key {
+		t.Errorf("key of a row moved by an insert: %q, then %q", before[0].Key, after[1].Key)
+	}
+}
+
+func TestReadPaymentsErrors(t *testing.T) {
+	cases := []struct {
+		name, format, input string
+		want                []string
+	}{
+		{"fields", "csv", alice + ",1,extra\n", []string{"row 1", "got 3 fields"}},
+		{"rows", "csv", alice + ",1\n0x1234,1\n" + bob + ",0\n" + bob + ",1.001\n" + common.Address{}.Hex() + ",1\n",
+			[]string{"4 invalid rows", "row 2: invalid recipient", "row 3: amount is zero", "row 4: amount", "row 5: recipient is the zero address"}},
+		{"quote", "csv", alice + ",\"1\n", []string{"quote"}},
+		{"json syntax", "json", `[{"recipient": "` + alice + `", "amount": 1}`, []string{"EOF"}},
+		{"json shape", "json", `{"recipient": "` + alice + `"}`, []string{"cannot unmarshal"}},
+		{"json rows", "json", `[{"recipient": "` + alice + `", "amount": "-1"}]`, []string{"row 1: amount"}},
+		{"empty", "csv", "recipient,amount\n", []string{"no payments"}},
+		{"format", "xlsx", "", []string{"unknown payments format"}},
+	}
+	for _, c := range cases {
+		_, err := erc20kit.ReadPayments(strings.NewReader(c.input), c.format, 2)
+		if err == nil {
+			t.Errorf("%s: no error", c.name)
+			continue
+		}
+		for _, want := range c.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q does not mention %q", c.name, err, want)
+			}
+		}
+	}
+}
+
+// TestJournalTornLine reopens a journal whose last line was cut short by a
+// crash and checks that earlier entries survive and new ones still parse.
+func TestJournalTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payments.journal")
+	journal, err := erc20kit.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(erc20kit.JournalEntry{Key: "a", Status: erc20kit.StatusMined}); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"key":"b","status":"se`)
+	f.Close()
+
+	journal, err = erc20kit.OpenJournal(path)
+	if err != nil {
+		t.Fatalf("reopen torn journal: %v", err)
+	}
+	if _, ok := journal.Entry("b"); ok {
+		t.Fatal("torn entry was loaded")
+	}
+	if err := journal.Record(erc20kit.JournalEntry{Key: "c", Status: erc20kit.StatusSent}); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	journal, err = erc20kit.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	for _, key := range []string{"a", "c"} {
+		if _, ok := journal.Entry(key); !ok {
+			t.Errorf("entry %q lost after a torn line", key)
+		}
+	}
+}
+
+// TestJournalReplay replays a journal left by an interrupted batch: a row
+// that was sent but not settled is resumed, never sent again, and only rows
+// without a transaction that could still pay them are left to send.
+func TestJournalReplay(t *testing.T) {
+	rows := strings.Join([]string{alice + ",1", bob + ",2", alice + ",3", bob + ",4", alice + ",5", alice + ",1"}, "\n")
+	payments, err := erc20kit.ReadPayments(strings.NewReader(rows), "csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "payments.journal")
+	journal, err := erc20kit.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []erc20kit.JournalEntry{
+		{Key: payments[0].Key, Status: erc20kit.StatusSent, Nonce: 0},
+		{Key: payments[0].Key, Status: erc20kit.StatusMined, Nonce: 0},
+		{Key: payments[2].Key, Status: erc20kit.StatusSent, Nonce: 2, RawTx: "0x02"},
+		{Key: payments[1].Key, Status: erc20kit.StatusSent, Nonce: 1, RawTx: "0x01"},
+		{Key: payments[3].Key, Status: erc20kit.StatusSendFailed, Nonce: 3},
+		{Key: payments[4].Key, Status: erc20kit.StatusReverted, Nonce: 4},
+	} {
+		if err := journal.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal.Close()
+
+	journal, err = erc20kit.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	sent := journal.Sent(payments)
+	if len(sent) != 2 || sent[0].Key != payments[1].Key || sent[1].Key != payments[2].Key || sent[0].RawTx != "0x01" {
+		t.Fatalf("sent entries %+v, want rows 2 and 3 in nonce order with their raw txs", sent)
+	}
+	var unpaid []int
+	for _, p := range journal.Unpaid(payments) {
+		unpaid = append(unpaid, p.Row)
+	}
+	// row 1 is mined and rows 2 and 3 are in flight
+	if want := []int{4, 5, 6}; len(unpaid) != len(want) || unpaid[0] != 4 || unpaid[1] != 5 || unpaid[2] != 6 {
+		t.Fatalf("unpaid rows %v, want %v", unpaid, want)
+	}
+}
+
+func TestIsRejected(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"nonce too low":                            true,
+		"replacement transaction underpriced":      true,
+		"max fee per gas less than block base fee": true,
+		"already known":                            false,
+		"context deadline exceeded":                false,
+	} {
+		if got := erc20kit.IsRejected(errors.New(msg)); got != want {
+			t.Errorf("IsRejected(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package erc20kit_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// testChain is a simulated chain of one test.
+type testChain struct {
+	*simulated.Chain
+	t   *testing.T
+	ctx context.Context
+}
+
+func newTestChain(t *testing.T) *testChain {
+	t.Helper()
+	return &testChain{Chain: simulated.NewTestChain(t), t: t, ctx: context.Background()}
+}
+
+// netVersionBackend is a node whose net_version differs from its
+// eth_chainId, as on networks that kept the network id of their parent.
+type netVersionBackend struct {
+	*simulated.Backend
+}
+
+func (netVersionBackend) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+// wei converts whole reference tokens.
+func wei(amount string) *big.Int {
+	return erc20kit.ToWei(amount, simulated.TokenDecimals)
+}
+
+// mined sends a transaction and waits for its successful receipt.
+func (c *testChain) mined(step string, send func() (*types.Transaction, error)) *types.Receipt {
+	c.t.Helper()
+	tx, err := send()
+	if err != nil {
+		c.t.Fatalf("%s: %v", step, err)
+	}
+	receipt, err := erc20kit.WaitForReceipt(c.ctx, c.Backend, tx.Hash(), erc20kit.WaitOptions{Timeout: time.Minute})
+	if err != nil {
+		c.t.Fatalf("%s: %v", step, err)
+	}
+	return receipt
+}
+
+// transfer sends amount of the reference token through the nonce manager
+// of from.
+func (c *testChain) transfer(from *erc20kit.Account, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return from.Send(c.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.Instance.Transfer(opts, to, amount)
+	})
+}
+
+func (c *testChain) balance(address common.Address) *big.Int {
+	c.t.Helper()
+	balance, err := c.Instance.BalanceOf(&bind.CallOpts{Context: c.ctx}, address)
+	if err != nil {
+		c.t.Fatalf("balance of %s: %v", address.Hex(), err)
+	}
+	return balance
+}
+
+func (c *testChain) expectBalance(name string, address common.Address, want *big.Int) {
+	c.t.Helper()
+	if balance := c.balance(address); balance.Cmp(want) != 0 {
+		c.t.Fatalf("%s balance = %s, want %s", name,
+			erc20kit.ToDecimal(balance, simulated.TokenDecimals), erc20kit.ToDecimal(want, simulated.TokenDecimals))
+	}
+}
+
+func (c *testChain) expectAllowance(owner, spender common.Address, want *big.Int) {
+	c.t.Helper()
+	allowance, err := c.Instance.Allowance(&bind.CallOpts{Context: c.ctx}, owner, spender)
+	if err != nil {
+		c.t.Fatalf("allowance: %v", err)
+	}
+	if allowance.Cmp(want) != 0 {
+		c.t.Fatalf("allowance = %s, want %s",
+			erc20kit.ToDecimal(allowance, simulated.TokenDecimals), erc20kit.ToDecimal(want, simulated.TokenDecimals))
+	}
+}
+
+// roundTrip passes v through JSON into out, as a file between machines
+// would.
+func roundTrip(t *testing.T, v, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+}
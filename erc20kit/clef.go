@@ -0,0 +1,155 @@
+package erc20kit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// DefaultClefTimeout leaves room for a person to review the request in
+// Clef before it is answered.
+const DefaultClefTimeout = 2 * time.Minute
+
+// ClefSigner signs through an external signer that speaks Clef's account_
+// API over HTTP or IPC. The key never enters the process.
+type ClefSigner struct {
+	// Timeout bounds one signing request.
+	Timeout time.Duration
+
+	client  *rpc.Client
+	address common.Address
+}
+
+// DialClef connects to a Clef endpoint, an http(s) URL or an IPC path, and
+// checks that it manages address.
+func DialClef(ctx context.Context, endpoint string, address common.Address) (*ClefSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, wrap(ErrDial, fmt.Errorf("clef: %w", err))
+	}
+	var managed []common.Address
+	if err := client.CallContext(ctx, &managed, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("clef: list accounts: %w", err)
+	}
+	for _, a := range managed {
+		if a == address {
+			return &ClefSigner{Timeout: DefaultClefTimeout, client: client, address: address}, nil
+		}
+	}
+	client.Close()
+	return nil, fmt.Errorf("clef does not manage %s", address.Hex())
+}
+
+func (s *ClefSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx asks Clef to sign tx and rejects the answer unless it is the
+// same transaction signed by the right key: Clef lets the user edit a
+// request, and an edited nonce or fee would go unnoticed by the caller.
+func (s *ClefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	// a pointer, as the addresses only marshal as strings through one
+	args := NewClefTxArgs(s.address, tx, chainID)
+	var res ClefSignResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", &args); err != nil {
+		return nil, fmt.Errorf("clef: sign transaction: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("clef: signed transaction: %w", err)
+	}
+	if !sameTx(tx, signed) {
+		return nil, fmt.Errorf("clef: signed transaction %s differs from the request", signed.Hash().Hex())
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil || from != s.address {
+		return nil, fmt.Errorf("clef: transaction %s is not signed by %s", signed.Hash().Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
+
+// SignTypedData asks Clef to sign EIP-712 data with account_signTypedData
+// and checks the signature recovers to the account.
+func (s *ClefSigner) SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error) {
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	var signature hexutil.Bytes
+	if err := s.client.CallContext(ctx, &signature, "account_signTypedData", s.address, data); err != nil {
+		return nil, fmt.Errorf("clef: sign typed data: %w", err)
+	}
+	if from, err := RecoverTypedData(data, signature); err != nil || from != s.address {
+		return nil, fmt.Errorf("clef: typed data is not signed by %s", s.address.Hex())
+	}
+	return signature, nil
+}
+
+// requestContext bounds one request by Timeout within ctx.
+func (s *ClefSigner) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.Timeout)
+}
+
+// Close disconnects from Clef.
+func (s *ClefSigner) Close() {
+	s.client.Close()
+}
+
+func sameTx(a, b *types.Transaction) bool {
+	toA, toB := a.To(), b.To()
+	return a.Type() == b.Type() &&
+		a.Nonce() == b.Nonce() &&
+		a.Gas() == b.Gas() &&
+		a.GasPrice().Cmp(b.GasPrice()) == 0 &&
+		a.GasTipCap().Cmp(b.GasTipCap()) == 0 &&
+		a.GasFeeCap().Cmp(b.GasFeeCap()) == 0 &&
+		a.Value().Cmp(b.Value()) == 0 &&
+		string(a.Data()) == string(b.Data()) &&
+		(toA == nil) == (toB == nil) && (toA == nil || *toA == *toB)
+}
+
+// ClefSignResult is what account_signTransaction returns.
+type ClefSignResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// NewClefTxArgs describes tx for Clef's account_signTransaction in the fee
+// model of its type.
+func NewClefTxArgs(from common.Address, tx *types.Transaction, chainID *big.Int) apitypes.SendTxArgs {
+	data := hexutil.Bytes(tx.Data())
+	args := apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(from),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if to := tx.To(); to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	return args
+}
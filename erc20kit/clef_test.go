@@ -0,0 +1,67 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// TestClefSigner replays transfer, approve and transferFrom with both keys
+// held by a Clef stub instead of the process.
+func TestClefSigner(t *testing.T) {
+	chain := newTestChain(t)
+	stub, err := simulated.NewClefStub(chain.Deployer.Signer, chain.User.Signer)
+	if err != nil {
+		t.Fatalf("start clef stub: %v", err)
+	}
+	defer stub.Close()
+
+	var accounts [2]*erc20kit.Account
+	for i, address := range []common.Address{chain.Deployer.Address, chain.User.Address} {
+		signer, err := erc20kit.DialClef(chain.ctx, stub.URL, address)
+		if err != nil {
+			t.Fatalf("dial clef: %v", err)
+		}
+		defer signer.Close()
+		if accounts[i], err = erc20kit.NewAccount(chain.ctx, chain.Backend, signer); err != nil {
+			t.Fatalf("clef account: %v", err)
+		}
+	}
+	deployer, user := accounts[0], accounts[1]
+	if _, err := erc20kit.DialClef(chain.ctx, stub.URL, common.HexToAddress("0x01")); err == nil {
+		t.Fatal("clef accepted an account it does not manage")
+	}
+
+	amount := wei("5")
+	chain.mined("transfer through clef", func() (*types.Transaction, error) {
+		return chain.Instance.Transfer(deployer.Auth, user.Address, amount)
+	})
+	chain.expectBalance("user", user.Address, amount)
+	chain.mined("approve through clef", func() (*types.Transaction, error) {
+		return chain.Instance.Approve(user.Auth, deployer.Address, amount)
+	})
+	chain.expectAllowance(user.Address, deployer.Address, amount)
+	chain.mined("transferFrom through clef", func() (*types.Transaction, error) {
+		return chain.Instance.TransferFrom(deployer.Auth, user.Address, deployer.Address, amount)
+	})
+	chain.expectBalance("user", user.Address, big.NewInt(0))
+	chain.expectAllowance(user.Address, deployer.Address, big.NewInt(0))
+
+	// a request is bounded by the caller's context, not only the timeout
+	ctx, cancel := context.WithCancel(chain.ctx)
+	cancel()
+	if _, err := user.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.GasLimit = 100000
+		return chain.Instance.Transfer(opts, deployer.Address, amount)
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("send with a cancelled context: got %v, want %v", err, context.Canceled)
+	}
+}
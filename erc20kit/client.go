@@ -0,0 +1,31 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Backend is the part of a node connection the kit relies on.
+// *ethclient.Client satisfies it.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	NetworkID(ctx context.Context) (*big.Int, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+var _ Backend = (*ethclient.Client)(nil)
+
+// Dial connects to an RPC node.
+func Dial(ctx context.Context, rpcNode string) (*ethclient.Client, error) {
+	client, err := ethclient.DialContext(ctx, rpcNode)
+	if err != nil {
+		return nil, wrap(ErrDial, err)
+	}
+	return client, nil
+}
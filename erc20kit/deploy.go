@@ -0,0 +1,38 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	reference "gb-sc-homework/contracts/ERC20"
+)
+
+// DeployERC20 is the send function deploying the mintable ERC20 of
+// contracts/ERC20, for Account.Send or PrepareTx. The address the
+// contract will have is stored in address once the transaction is built.
+func DeployERC20(backend Backend, name, symbol string, decimals uint8, initialSupply *big.Int, address *common.Address) func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		deployed, tx, _, err := reference.DeployERC20(opts, backend, name, symbol, decimals, initialSupply)
+		if err != nil {
+			return nil, err
+		}
+		*address = deployed
+		return tx, nil
+	}
+}
+
+// DeployERC20 deploys the mintable ERC20 from the account, which becomes
+// its owner and receives the whole initial supply. The contract exists
+// once the transaction is mined.
+func (a *Account) DeployERC20(ctx context.Context, name, symbol string, decimals uint8, initialSupply *big.Int) (common.Address, *types.Transaction, error) {
+	var address common.Address
+	tx, err := a.Send(ctx, DeployERC20(a.backend, name, symbol, decimals, initialSupply, &address))
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return address, tx, nil
+}
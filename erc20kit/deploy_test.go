@@ -0,0 +1,60 @@
+package erc20kit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	reference "gb-sc-homework/contracts/ERC20"
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/registry"
+)
+
+// TestDeployERC20 deploys the mintable ERC20 of contracts/ERC20 and checks
+// its metadata, its owner and that only the owner can mint.
+func TestDeployERC20(t *testing.T) {
+	chain := newTestChain(t)
+	supply := erc20kit.ToWei("1000", 6)
+	var address common.Address
+	chain.mined("deploy ERC20", func() (*types.Transaction, error) {
+		var (
+			tx  *types.Transaction
+			err error
+		)
+		address, tx, err = chain.Deployer.DeployERC20(chain.ctx, "Mintable Token", "MNT", 6, supply)
+		return tx, err
+	})
+	meta, err := registry.Fetch(chain.ctx, chain.Backend, address)
+	if err != nil {
+		t.Fatalf("deployed token: %v", err)
+	}
+	if meta.Name != "Mintable Token" || meta.Symbol != "MNT" || meta.Decimals != 6 || meta.TotalSupply != supply.String() {
+		t.Fatalf("deployed token is %s (%s), %d decimals, supply %s", meta.Name, meta.Symbol, meta.Decimals, meta.TotalSupply)
+	}
+
+	deployed, err := reference.NewERC20(address, chain.Backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, err := deployed.Owner(&bind.CallOpts{Context: chain.ctx}); err != nil || owner != chain.Deployer.Address {
+		t.Fatalf("owner %s (%v), want the deployer", owner.Hex(), err)
+	}
+	minted := erc20kit.ToWei("5", 6)
+	chain.mined("mint", func() (*types.Transaction, error) {
+		return chain.Deployer.Send(chain.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return deployed.Mint(opts, chain.User.Address, minted)
+		})
+	})
+	if balance, err := deployed.BalanceOf(&bind.CallOpts{Context: chain.ctx}, chain.User.Address); err != nil || balance.Cmp(minted) != 0 {
+		t.Fatalf("minted balance %v (%v), want %s", balance, err, minted)
+	}
+	_, err = chain.User.Send(chain.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return deployed.Mint(opts, chain.User.Address, minted)
+	})
+	if err == nil || !strings.Contains(err.Error(), "Ownable: caller is not the owner") {
+		t.Errorf("mint by another account: %v", err)
+	}
+}
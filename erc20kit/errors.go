@@ -0,0 +1,57 @@
+package erc20kit
+
+import "errors"
+
+// Error kinds returned by the kit. Match them with errors.Is; the wrapped
+// *Error carries the underlying cause.
+var (
+	ErrDial        = errors.New("dial rpc node")
+	ErrPrivateKey  = errors.New("invalid private key")
+	ErrChainID     = errors.New("chain id lookup")
+	ErrWrongChain  = errors.New("connected to the wrong chain")
+	ErrGasPrice    = errors.New("gas price lookup")
+	ErrNonce       = errors.New("nonce lookup")
+	ErrReceipt     = errors.New("fetch receipt")
+	ErrTxLookup    = errors.New("fetch transaction")
+	ErrHeader      = errors.New("fetch header")
+	ErrTimeout     = errors.New("timed out waiting for receipt")
+	ErrReorged     = errors.New("transaction dropped by reorg")
+	ErrReverted    = errors.New("transaction reverted")
+	ErrNotPending  = errors.New("transaction is not pending")
+	ErrWouldRevert = errors.New("call would revert")
+	ErrNoPermit    = errors.New("token does not support permit")
+	ErrBadSigner   = errors.New("signed by another account")
+
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrNegativeAmount  = errors.New("negative amount")
+	ErrTooManyDecimals = errors.New("too many fractional digits")
+	ErrUnsupportedType = errors.New("unsupported amount type")
+)
+
+// Error wraps a failure from the node, key handling or amount conversion
+// with one of the Err* kinds above.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func wrap(kind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of this error, so that
+// errors.Is(err, ErrDial) works without unwrapping by hand.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
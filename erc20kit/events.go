@@ -0,0 +1,9 @@
+package erc20kit
+
+import "github.com/ethereum/go-ethereum/crypto"
+
+// Topics of the ERC20 events, for matching logs before unpacking them.
+var (
+	TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	ApprovalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+)
@@ -0,0 +1,222 @@
+package erc20kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultGasLimitMargin is the percentage added on top of EstimateGas.
+const DefaultGasLimitMargin = 20
+
+// GasBackend is what gas strategies query. bind.ContractTransactor and
+// *ethclient.Client satisfy it.
+type GasBackend interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// GasFees are the fees for one transaction: either GasPrice for a legacy
+// transaction or GasFeeCap and GasTipCap for an EIP-1559 one.
+type GasFees struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+// GasStrategy decides the fees of a transaction. It is asked again for
+// every transaction sent through Account.Send.
+type GasStrategy interface {
+	Fees(ctx context.Context, backend GasBackend) (GasFees, error)
+}
+
+// AutoGas uses DynamicGas on chains with a base fee and LegacyGas otherwise.
+type AutoGas struct{}
+
+func (AutoGas) Fees(ctx context.Context, backend GasBackend) (GasFees, error) {
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return GasFees{}, wrap(ErrGasPrice, err)
+	}
+	if head.BaseFee != nil {
+		return DynamicGas{}.feesAt(ctx, backend, head)
+	}
+	return LegacyGas{}.Fees(ctx, backend)
+}
+
+// LegacyGas prices transactions at the node's suggested gas price.
+type LegacyGas struct{}
+
+func (LegacyGas) Fees(ctx context.Context, backend GasBackend) (GasFees, error) {
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return GasFees{}, wrap(ErrGasPrice, err)
+	}
+	return GasFees{GasPrice: gasPrice}, nil
+}
+
+// DynamicGas builds EIP-1559 fees from the node's suggested tip and the
+// latest base fee. The fee cap leaves room for the base fee to grow by
+// BaseFeeMultiplier (2 when zero) before the transaction stops being
+// includable.
+type DynamicGas struct {
+	BaseFeeMultiplier int64
+}
+
+func (d DynamicGas) Fees(ctx context.Context, backend GasBackend) (GasFees, error) {
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return GasFees{}, wrap(ErrGasPrice, err)
+	}
+	return d.feesAt(ctx, backend, head)
+}
+
+func (d DynamicGas) feesAt(ctx context.Context, backend GasBackend, head *types.Header) (GasFees, error) {
+	if head.BaseFee == nil {
+		return GasFees{}, wrap(ErrGasPrice, errors.New("chain has no base fee, use legacy pricing"))
+	}
+	tip, err := backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return GasFees{}, wrap(ErrGasPrice, err)
+	}
+	multiplier := d.BaseFeeMultiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(multiplier))
+	feeCap.Add(feeCap, tip)
+	return GasFees{GasFeeCap: feeCap, GasTipCap: tip}, nil
+}
+
+// FixedGas always returns the same fees.
+type FixedGas GasFees
+
+func (f FixedGas) Fees(ctx context.Context, backend GasBackend) (GasFees, error) {
+	return GasFees(f), nil
+}
+
+// MultiplierGas scales every fee of Base by Percent/100, e.g. 125 to pay
+// 25% over the suggestion.
+type MultiplierGas struct {
+	Base    GasStrategy
+	Percent uint64
+}
+
+func (m MultiplierGas) Fees(ctx context.Context, backend GasBackend) (GasFees, error) {
+	fees, err := m.Base.Fees(ctx, backend)
+	if err != nil {
+		return GasFees{}, err
+	}
+	return GasFees{
+		GasPrice:  scalePercent(fees.GasPrice, m.Percent),
+		GasFeeCap: scalePercent(fees.GasFeeCap, m.Percent),
+		GasTipCap: scalePercent(fees.GasTipCap, m.Percent),
+	}, nil
+}
+
+func scalePercent(v *big.Int, percent uint64) *big.Int {
+	if v == nil {
+		return nil
+	}
+	v = new(big.Int).Mul(v, new(big.Int).SetUint64(percent))
+	return v.Div(v, big.NewInt(100))
+}
+
+// ParseGasStrategy parses the strategy names accepted on the command line:
+// "auto", "legacy", "eip1559", "fixed:<gwei>", optionally followed by a
+// multiplier above zero such as "legacy*1.25".
+func ParseGasStrategy(s string) (GasStrategy, error) {
+	name, factor, multiplied := s, "", false
+	if i := strings.IndexByte(s, '*'); i >= 0 {
+		name, factor, multiplied = s[:i], s[i+1:], true
+	}
+
+	var strategy GasStrategy
+	switch {
+	case name == "auto" || name == "":
+		strategy = AutoGas{}
+	case name == "legacy":
+		strategy = LegacyGas{}
+	case name == "eip1559":
+		strategy = DynamicGas{}
+	case strings.HasPrefix(name, "fixed:"):
+		gasPrice, err := ToWeiExact(strings.TrimPrefix(name, "fixed:"), 9)
+		if err != nil {
+			return nil, fmt.Errorf("gas strategy %q: %w", s, err)
+		}
+		strategy = FixedGas{GasPrice: gasPrice}
+	default:
+		return nil, fmt.Errorf("unknown gas strategy %q", s)
+	}
+
+	if multiplied {
+		percent, err := ToWeiExact(factor, 2)
+		if err != nil || percent.Sign() <= 0 || !percent.IsUint64() {
+			return nil, fmt.Errorf("gas strategy %q: invalid multiplier %q", s, factor)
+		}
+		strategy = MultiplierGas{Base: strategy, Percent: percent.Uint64()}
+	}
+	return strategy, nil
+}
+
+// applyFees sets fees on opts, clearing the fields of the other pricing
+// model so the binding builds the matching transaction type.
+func applyFees(opts *bind.TransactOpts, fees GasFees) {
+	opts.GasPrice = fees.GasPrice
+	opts.GasFeeCap = fees.GasFeeCap
+	opts.GasTipCap = fees.GasTipCap
+}
+
+// withGasMargin raises the gas limit the binding estimated by percent
+// before the transaction is signed.
+func withGasMargin(signer bind.SignerFn, percent uint64) bind.SignerFn {
+	return func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		gas := tx.Gas() + tx.Gas()*percent/100
+		return signer(from, withGasLimit(tx, gas))
+	}
+}
+
+// withGasLimit returns an unsigned copy of tx with another gas limit.
+func withGasLimit(tx *types.Transaction, gas uint64) *types.Transaction {
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tx.GasTipCap(),
+			GasFeeCap:  tx.GasFeeCap(),
+			Gas:        gas,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   tx.GasPrice(),
+			Gas:        gas,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: tx.GasPrice(),
+			Gas:      gas,
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package erc20kit_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// TestGasStrategies sends one transfer per gas strategy and checks the
+// transaction type, the fixed price and the margin on the gas limit.
+func TestGasStrategies(t *testing.T) {
+	chain := newTestChain(t)
+	from := chain.Deployer
+
+	cases := []struct {
+		spec     string
+		txType   uint8
+		gasPrice *big.Int
+	}{
+		{"legacy", types.LegacyTxType, nil},
+		{"eip1559", types.DynamicFeeTxType, nil},
+		{"fixed:2", types.LegacyTxType, big.NewInt(2000000000)},
+		{"eip1559*1.5", types.DynamicFeeTxType, nil},
+	}
+	for _, c := range cases {
+		strategy, err := erc20kit.ParseGasStrategy(c.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from.Gas = strategy
+
+		var tx *types.Transaction
+		receipt := chain.mined("transfer with gas "+c.spec, func() (*types.Transaction, error) {
+			var err error
+			tx, err = chain.transfer(from, chain.User.Address, wei("1"))
+			return tx, err
+		})
+		if tx.Type() != c.txType {
+			t.Errorf("gas %s: tx type %d, want %d", c.spec, tx.Type(), c.txType)
+		}
+		if c.gasPrice != nil && tx.GasPrice().Cmp(c.gasPrice) != 0 {
+			t.Errorf("gas %s: gas price %s, want %s", c.spec, tx.GasPrice(), c.gasPrice)
+		}
+		if minGas := receipt.GasUsed + receipt.GasUsed*from.GasLimitMargin/100; tx.Gas() < minGas {
+			t.Errorf("gas %s: gas limit %d has no %d%% margin over %d used", c.spec, tx.Gas(), from.GasLimitMargin, receipt.GasUsed)
+		}
+	}
+	chain.expectBalance("user", chain.User.Address, wei("4"))
+}
+
+func TestParseGasStrategyRejects(t *testing.T) {
+	for _, spec := range []string{"cheap", "fixed:", "fixed:-1", "legacy*", "legacy*0", "eip1559*0.00", "auto*-1", "legacy*1.255", "legacy*x"} {
+		if _, err := erc20kit.ParseGasStrategy(spec); err == nil {
+			t.Errorf("ParseGasStrategy(%q) accepted", spec)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// Defaults used when the Indexer fields are zero.
+const (
+	DefaultChunkSize    = 5000
+	DefaultPollInterval = 5 * time.Second
+	// DefaultReorgDepth is how far below the head event block hashes are
+	// checked against the chain before they are stored.
+	DefaultReorgDepth = 64
+	// MaxBackoff caps the delay between retries of a following indexer
+	// whose node fails.
+	MaxBackoff = time.Minute
+)
+
+// errBlockChanged means a reorg replaced a block while its range was being
+// indexed; the range is fetched again from a fresh head.
+var errBlockChanged = errors.New("block changed while indexing")
+
+// nodeError marks a failed request to the node, as opposed to one to the
+// store, so a following indexer knows to retry it.
+type nodeError struct {
+	err error
+}
+
+func (e *nodeError) Error() string { return e.err.Error() }
+func (e *nodeError) Unwrap() error { return e.err }
+
+// Backend is what the indexer reads from a node. *ethclient.Client
+// satisfies it.
+type Backend interface {
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Indexer copies one token's Transfer and Approval events into a Store.
+type Indexer struct {
+	Store   *Store
+	Token   common.Address
+	Backend Backend
+
+	// ChunkSize is the widest block range asked for at once. A range the
+	// provider refuses is halved until it goes through, and grows back
+	// after each success.
+	ChunkSize uint64
+	// Confirmations keeps the indexer this many blocks behind the head.
+	Confirmations uint64
+	PollInterval  time.Duration
+	ReorgDepth    uint64
+
+	// Progress, when set, is called after each committed range.
+	Progress func(from, to uint64, events int)
+	// Reorg, when set, is called after a rollback to block to.
+	Reorg func(from, to uint64)
+	// OnError, when set, is told about node failures a following indexer
+	// retries.
+	OnError func(err error)
+
+	filterer *token.ERC20tokenFilterer
+}
+
+// New returns an indexer with default settings.
+func New(store *Store, tokenAddress common.Address, backend Backend) (*Indexer, error) {
+	filterer, err := token.NewERC20tokenFilterer(tokenAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Indexer{
+		Store:        store,
+		Token:        tokenAddress,
+		Backend:      backend,
+		ChunkSize:    DefaultChunkSize,
+		PollInterval: DefaultPollInterval,
+		ReorgDepth:   DefaultReorgDepth,
+		filterer:     filterer,
+	}, nil
+}
+
+// Run indexes from the stored cursor, or from start on a fresh store, up
+// to the head. With follow it then keeps up with new blocks until ctx is
+// done, retrying node failures with growing delays.
+func (ix *Indexer) Run(ctx context.Context, start uint64, follow bool) error {
+	maxChunk := ix.ChunkSize
+	if maxChunk == 0 {
+		maxChunk = DefaultChunkSize
+	}
+	interval := ix.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	// catchUp indexes to the current head in ranges of at most maxChunk
+	// blocks
+	catchUp := func() error {
+		next, err := ix.resume(ctx, start)
+		if err != nil {
+			return err
+		}
+		head, err := ix.Backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return &nodeError{fmt.Errorf("fetch head: %w", err)}
+		}
+		target := head.Number.Uint64()
+		if target < ix.Confirmations {
+			target = 0
+		} else {
+			target -= ix.Confirmations
+		}
+
+		return erc20kit.WalkLogRanges(ctx, next, target, maxChunk, func(from, to uint64) error {
+			n, err := ix.index(ctx, from, to, head.Number.Uint64())
+			if err != nil {
+				return err
+			}
+			if ix.Progress != nil {
+				ix.Progress(from, to, n)
+			}
+			return nil
+		})
+	}
+
+	backoff := interval
+	for {
+		err := catchUp()
+		var nodeErr *nodeError
+		switch {
+		case err == nil:
+			if !follow {
+				return nil
+			}
+			backoff = interval
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, errBlockChanged):
+			// start over from a fresh head straight away
+			continue
+		case !follow || !errors.As(err, &nodeErr):
+			return err
+		default:
+			if ix.OnError != nil {
+				ix.OnError(fmt.Errorf("retrying in %s: %w", backoff, err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if err != nil {
+			if backoff *= 2; backoff > MaxBackoff {
+				backoff = MaxBackoff
+			}
+		}
+	}
+}
+
+// resume returns the next block to index, rolling back first if the
+// cursor block is no longer canonical.
+func (ix *Indexer) resume(ctx context.Context, start uint64) (uint64, error) {
+	cursor, ok, err := ix.Store.Cursor(ix.Token)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return start, nil
+	}
+	canonical, err := ix.canonical(ctx, cursor)
+	if err != nil || canonical {
+		return cursor.Number + 1, err
+	}
+
+	// walk back through the remembered blocks to the newest one still on
+	// the chain; everything below it is canonical too
+	for below := cursor.Number; ; {
+		blocks, err := ix.Store.BlocksBefore(ix.Token, below, 64)
+		if err != nil {
+			return 0, err
+		}
+		for _, b := range blocks {
+			canonical, err := ix.canonical(ctx, b)
+			if err != nil {
+				return 0, err
+			}
+			if canonical {
+				if err := ix.Store.Rollback(ix.Token, b); err != nil {
+					return 0, err
+				}
+				if ix.Reorg != nil {
+					ix.Reorg(cursor.Number, b.Number)
+				}
+				return b.Number + 1, nil
+			}
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		below = blocks[len(blocks)-1].Number
+	}
+
+	if err := ix.Store.Reset(ix.Token); err != nil {
+		return 0, err
+	}
+	if ix.Reorg != nil {
+		ix.Reorg(cursor.Number, start)
+	}
+	return start, nil
+}
+
+func (ix *Indexer) canonical(ctx context.Context, b Block) (bool, error) {
+	header, err := ix.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(b.Number))
+	if err != nil {
+		return false, &nodeError{fmt.Errorf("fetch block %d: %w", b.Number, err)}
+	}
+	return header.Hash() == b.Hash, nil
+}
+
+// index fetches and commits the events of blocks from..to.
+func (ix *Indexer) index(ctx context.Context, from, to, head uint64) (int, error) {
+	events, err := ix.fetch(ctx, from, to)
+	if err != nil {
+		return 0, &nodeError{fmt.Errorf("fetch logs %d-%d: %w", from, to, err)}
+	}
+	header, err := ix.Backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return 0, &nodeError{fmt.Errorf("fetch block %d: %w", to, err)}
+	}
+
+	// near the head a reorg may have replaced blocks between the log query
+	// and the header lookup; such a range is simply fetched again
+	depth := ix.ReorgDepth
+	if depth == 0 {
+		depth = DefaultReorgDepth
+	}
+	if head-to < depth {
+		for _, e := range events {
+			canonical, err := ix.canonical(ctx, Block{Number: e.BlockNumber, Hash: e.BlockHash})
+			if err != nil {
+				return 0, err
+			}
+			if !canonical {
+				return 0, fmt.Errorf("block %d: %w", e.BlockNumber, errBlockChanged)
+			}
+		}
+	}
+
+	cursor := Block{Number: to, Hash: header.Hash()}
+	if err := ix.Store.Commit(ix.Token, events, cursor); err != nil {
+		return 0, fmt.Errorf("store blocks %d-%d: %w", from, to, err)
+	}
+	return len(events), nil
+}
+
+func (ix *Indexer) fetch(ctx context.Context, from, to uint64) ([]Event, error) {
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+
+	var events []Event
+	transfers, err := ix.filterer.FilterTransfer(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for transfers.Next() {
+		e := transfers.Event
+		events = append(events, ix.event(KindTransfer, e.From, e.To, e.Value, e.Raw))
+	}
+	transfers.Close()
+	if err := transfers.Error(); err != nil {
+		return nil, err
+	}
+
+	approvals, err := ix.filterer.FilterApproval(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for approvals.Next() {
+		e := approvals.Event
+		events = append(events, ix.event(KindApproval, e.Owner, e.Spender, e.Value, e.Raw))
+	}
+	approvals.Close()
+	if err := approvals.Error(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (ix *Indexer) event(kind string, from, to common.Address, value *big.Int, raw types.Log) Event {
+	return Event{
+		Token:       ix.Token,
+		Kind:        kind,
+		From:        from,
+		To:          to,
+		Value:       value,
+		BlockNumber: raw.BlockNumber,
+		BlockHash:   raw.BlockHash,
+		TxHash:      raw.TxHash,
+		LogIndex:    raw.Index,
+	}
+}
@@ -0,0 +1,202 @@
+package index_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/index"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// fixture is a simulated chain with a few transfers and a scratch index of
+// its token.
+type fixture struct {
+	t     *testing.T
+	ctx   context.Context
+	chain *simulated.Chain
+	store *index.Store
+	ix    *index.Indexer
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	chain := simulated.NewTestChain(t)
+	store, err := index.Open(filepath.Join(t.TempDir(), "token.db"))
+	if err != nil {
+		t.Fatalf("open index: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ix, err := index.New(store, chain.Token, chain.Backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// small chunks so the backfill takes several ranges
+	ix.ChunkSize = 3
+
+	f := &fixture{t: t, ctx: context.Background(), chain: chain, store: store, ix: ix}
+	for i := 0; i < 5; i++ {
+		f.transfer("1")
+	}
+	return f
+}
+
+func (f *fixture) transfer(amount string) {
+	f.t.Helper()
+	_, err := f.chain.Deployer.Send(f.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return f.chain.Instance.Transfer(opts, f.chain.User.Address, erc20kit.ToWei(amount, simulated.TokenDecimals))
+	})
+	if err != nil {
+		f.t.Fatalf("transfer: %v", err)
+	}
+}
+
+// indexed runs the indexer to the head and returns the number of stored
+// transfers, failing unless it is want (any number when want is -1).
+func (f *fixture) indexed(want int) int {
+	f.t.Helper()
+	if err := f.ix.Run(f.ctx, 0, false); err != nil {
+		f.t.Fatalf("index: %v", err)
+	}
+	n, err := f.store.Count(f.chain.Token, index.KindTransfer)
+	if err != nil {
+		f.t.Fatalf("index: %v", err)
+	}
+	if want >= 0 && n != want {
+		f.t.Fatalf("index has %d transfers, want %d", n, want)
+	}
+	return n
+}
+
+// TestIndexReorg backfills the chain and compares it with the node, then
+// mines a transfer, forks it away and checks that the indexer rolls it
+// back.
+func TestIndexReorg(t *testing.T) {
+	f := newFixture(t)
+	reorged := false
+	f.ix.Reorg = func(from, to uint64) { reorged = true }
+
+	transfers := f.indexed(-1)
+	it, err := f.chain.Instance.FilterTransfer(&bind.FilterOpts{Context: f.ctx}, nil, nil)
+	if err != nil {
+		t.Fatalf("filter transfers: %v", err)
+	}
+	onChain := 0
+	for it.Next() {
+		onChain++
+	}
+	it.Close()
+	if transfers != onChain {
+		t.Fatalf("index has %d transfers, the node %d", transfers, onChain)
+	}
+
+	forkPoint := f.chain.Backend.Blockchain().CurrentBlock().Hash()
+	f.transfer("1")
+	f.indexed(transfers + 1)
+
+	// replace the block with the transfer by a longer empty chain
+	if err := f.chain.Backend.Fork(f.ctx, forkPoint); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	f.chain.Backend.Commit()
+	f.chain.Backend.Commit()
+	f.indexed(transfers)
+	if !reorged {
+		t.Error("indexer did not report the reorg")
+	}
+}
+
+// flakyBackend fails the first head lookups like a node that is down, and
+// refuses log queries wider than maxRange blocks like a hosted provider.
+type flakyBackend struct {
+	*simulated.Backend
+	maxRange uint64
+
+	mu       sync.Mutex
+	failures int
+	widest   uint64
+}
+
+func (b *flakyBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if number == nil && b.failures > 0 {
+		b.failures--
+		return nil, errors.New("connection refused")
+	}
+	return b.Backend.HeaderByNumber(ctx, number)
+}
+
+func (b *flakyBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	width := query.ToBlock.Uint64() - query.FromBlock.Uint64() + 1
+	if width > b.maxRange {
+		return nil, errors.New("exceed maximum block range: 2")
+	}
+	if width > b.widest {
+		b.widest = width
+	}
+	return b.Backend.FilterLogs(ctx, query)
+}
+
+// TestIndexFollowRetries follows a node that is down at first and limits
+// log ranges, and checks that the indexer waits it out and narrows its
+// queries instead of giving up.
+func TestIndexFollowRetries(t *testing.T) {
+	f := newFixture(t)
+	backend := &flakyBackend{Backend: f.chain.Backend, maxRange: 2, failures: 2}
+	ix, err := index.New(f.store, f.chain.Token, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.ChunkSize = 8
+	ix.PollInterval = 10 * time.Millisecond
+	retried := 0
+	ix.OnError = func(err error) { retried++ }
+
+	if err := ix.Run(f.ctx, 0, false); err == nil {
+		t.Fatal("indexed without following while the node was down")
+	}
+
+	ctx, cancel := context.WithTimeout(f.ctx, 5*time.Second)
+	defer cancel()
+	synced := make(chan struct{})
+	head := f.chain.Backend.Blockchain().CurrentBlock().NumberU64()
+	ix.Progress = func(from, to uint64, events int) {
+		if to == head {
+			close(synced)
+		}
+	}
+	done := make(chan error, 1)
+	go func() { done <- ix.Run(ctx, 0, true) }()
+	select {
+	case <-synced:
+	case err := <-done:
+		t.Fatalf("follow returned before ctx was done: %v", err)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("follow returned %v, want %v", err, context.Canceled)
+	}
+
+	if retried != 1 {
+		t.Errorf("%d retries reported, want 1", retried)
+	}
+	if backend.widest != backend.maxRange {
+		t.Errorf("widest log query %d blocks, want %d", backend.widest, backend.maxRange)
+	}
+	n, err := f.store.Count(f.chain.Token, index.KindTransfer)
+	if err != nil || n == 0 {
+		t.Fatalf("index has %d transfers, %v", n, err)
+	}
+}
@@ -0,0 +1,191 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	token "gb-sc-homework/contracts/IERC20"
+)
+
+// AllowanceKey identifies an owner→spender allowance.
+type AllowanceKey struct {
+	Owner   common.Address
+	Spender common.Address
+}
+
+// Ledger is a token's state as derived from its indexed events alone.
+//
+// Balances follow Transfer events, with transfers from the zero address
+// minting and to it burning. Allowances are the value of the last
+// Approval event, so a token that spends allowances in transferFrom
+// without emitting Approval drifts from the chain; Reconcile shows where.
+type Ledger struct {
+	Token       common.Address
+	Block       uint64
+	TotalSupply *big.Int
+	Balances    map[common.Address]*big.Int
+	Allowances  map[AllowanceKey]*big.Int
+}
+
+// LedgerAt replays the events stored for token up to and including block.
+func (s *Store) LedgerAt(tokenAddress common.Address, block uint64) (*Ledger, error) {
+	cursor, ok, err := s.Cursor(tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || cursor.Number < block {
+		return nil, fmt.Errorf("token %s is only indexed up to block %d", tokenAddress.Hex(), cursor.Number)
+	}
+
+	rows, err := s.db.Query(`SELECT kind, src, dst, value FROM events
+		WHERE token = ? AND block_number <= ? ORDER BY block_number, log_index`, tokenAddress.Hex(), block)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := &Ledger{
+		Token:       tokenAddress,
+		Block:       block,
+		TotalSupply: new(big.Int),
+		Balances:    make(map[common.Address]*big.Int),
+		Allowances:  make(map[AllowanceKey]*big.Int),
+	}
+	for rows.Next() {
+		var kind, src, dst, value string
+		if err := rows.Scan(&kind, &src, &dst, &value); err != nil {
+			return nil, err
+		}
+		amount, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return nil, fmt.Errorf("bad value %q in index", value)
+		}
+		from, to := common.HexToAddress(src), common.HexToAddress(dst)
+
+		switch kind {
+		case KindTransfer:
+			if from == (common.Address{}) {
+				l.TotalSupply.Add(l.TotalSupply, amount)
+			} else {
+				l.balance(from).Sub(l.balance(from), amount)
+			}
+			if to == (common.Address{}) {
+				l.TotalSupply.Sub(l.TotalSupply, amount)
+			} else {
+				l.balance(to).Add(l.balance(to), amount)
+			}
+		case KindApproval:
+			l.Allowances[AllowanceKey{Owner: from, Spender: to}] = amount
+		}
+	}
+	return l, rows.Err()
+}
+
+func (l *Ledger) balance(holder common.Address) *big.Int {
+	b, ok := l.Balances[holder]
+	if !ok {
+		b = new(big.Int)
+		l.Balances[holder] = b
+	}
+	return b
+}
+
+// Holders returns every address that ever held the token, largest balance
+// first.
+func (l *Ledger) Holders() []common.Address {
+	holders := make([]common.Address, 0, len(l.Balances))
+	for holder := range l.Balances {
+		holders = append(holders, holder)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if c := l.Balances[holders[i]].Cmp(l.Balances[holders[j]]); c != 0 {
+			return c > 0
+		}
+		return holders[i].Hex() < holders[j].Hex()
+	})
+	return holders
+}
+
+// AllowanceKeys returns every owner and spender pair ever approved,
+// ordered by spender and then owner.
+func (l *Ledger) AllowanceKeys() []AllowanceKey {
+	keys := make([]AllowanceKey, 0, len(l.Allowances))
+	for key := range l.Allowances {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Spender != keys[j].Spender {
+			return keys[i].Spender.Hex() < keys[j].Spender.Hex()
+		}
+		return keys[i].Owner.Hex() < keys[j].Owner.Hex()
+	})
+	return keys
+}
+
+// Mismatch is a value the ledger and the token disagree on. Owner and
+// Spender are set as the kind requires.
+type Mismatch struct {
+	Kind    string // "totalSupply", "balance" or "allowance"
+	Owner   common.Address
+	Spender common.Address
+	Ledger  *big.Int
+	OnChain *big.Int
+}
+
+func (m Mismatch) String() string {
+	switch m.Kind {
+	case "balance":
+		return fmt.Sprintf("balance of %s: ledger %s, chain %s", m.Owner.Hex(), m.Ledger, m.OnChain)
+	case "allowance":
+		return fmt.Sprintf("allowance %s -> %s: ledger %s, chain %s", m.Owner.Hex(), m.Spender.Hex(), m.Ledger, m.OnChain)
+	default:
+		return fmt.Sprintf("%s: ledger %s, chain %s", m.Kind, m.Ledger, m.OnChain)
+	}
+}
+
+// Reconcile compares the ledger with TotalSupply, BalanceOf and Allowance
+// read from the chain at the ledger's block. A block older than the
+// node's recent state needs an archive node.
+func (l *Ledger) Reconcile(ctx context.Context, caller bind.ContractCaller) ([]Mismatch, error) {
+	instance, err := token.NewERC20tokenCaller(l.Token, caller)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(l.Block)}
+
+	var mismatches []Mismatch
+	supply, err := instance.TotalSupply(opts)
+	if err != nil {
+		return nil, fmt.Errorf("total supply at block %d: %w", l.Block, err)
+	}
+	if supply.Cmp(l.TotalSupply) != 0 {
+		mismatches = append(mismatches, Mismatch{Kind: "totalSupply", Ledger: l.TotalSupply, OnChain: supply})
+	}
+
+	for _, holder := range l.Holders() {
+		balance, err := instance.BalanceOf(opts, holder)
+		if err != nil {
+			return nil, fmt.Errorf("balance of %s at block %d: %w", holder.Hex(), l.Block, err)
+		}
+		if balance.Cmp(l.Balances[holder]) != 0 {
+			mismatches = append(mismatches, Mismatch{Kind: "balance", Owner: holder, Ledger: l.Balances[holder], OnChain: balance})
+		}
+	}
+
+	for key, allowance := range l.Allowances {
+		onChain, err := instance.Allowance(opts, key.Owner, key.Spender)
+		if err != nil {
+			return nil, fmt.Errorf("allowance %s -> %s at block %d: %w", key.Owner.Hex(), key.Spender.Hex(), l.Block, err)
+		}
+		if onChain.Cmp(allowance) != 0 {
+			mismatches = append(mismatches, Mismatch{Kind: "allowance", Owner: key.Owner, Spender: key.Spender, Ledger: allowance, OnChain: onChain})
+		}
+	}
+	sort.SliceStable(mismatches, func(i, j int) bool { return mismatches[i].String() < mismatches[j].String() })
+	return mismatches, nil
+}
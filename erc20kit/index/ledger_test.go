@@ -0,0 +1,64 @@
+package index_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// TestLedgerReconcile derives the ledger at the indexed head and checks it
+// against the token, then checks the deployer's balance at an earlier
+// block.
+func TestLedgerReconcile(t *testing.T) {
+	f := newFixture(t)
+	spenders := []common.Address{{2}, {1}}
+	for _, spender := range spenders {
+		_, err := f.chain.Deployer.Send(f.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return f.chain.Instance.Approve(opts, spender, big.NewInt(1))
+		})
+		if err != nil {
+			t.Fatalf("approve: %v", err)
+		}
+	}
+	f.indexed(-1)
+
+	cursor, _, err := f.store.Cursor(f.chain.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger, err := f.store.LedgerAt(f.chain.Token, cursor.Number)
+	if err != nil {
+		t.Fatalf("ledger: %v", err)
+	}
+	mismatches, err := ledger.Reconcile(f.ctx, f.chain.Backend)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(mismatches) > 0 {
+		t.Fatalf("reconcile: %v", mismatches)
+	}
+	if ledger.TotalSupply.Cmp(simulated.InitialSupply) != 0 {
+		t.Errorf("ledger total supply %s, want %s", ledger.TotalSupply, simulated.InitialSupply)
+	}
+	if len(ledger.Balances) != 2 {
+		t.Errorf("ledger has %d holders, want 2", len(ledger.Balances))
+	}
+	keys := ledger.AllowanceKeys()
+	if len(keys) != 2 || keys[0].Spender != spenders[1] || keys[1].Spender != spenders[0] {
+		t.Errorf("allowance keys %v, want spenders %v and %v in order", keys, spenders[1].Hex(), spenders[0].Hex())
+	}
+
+	// at the deployment block the deployer held everything
+	first, err := f.store.LedgerAt(f.chain.Token, 1)
+	if err != nil {
+		t.Fatalf("ledger: %v", err)
+	}
+	if got := first.Balances[f.chain.Deployer.Address]; got == nil || got.Cmp(simulated.InitialSupply) != 0 {
+		t.Errorf("ledger at block 1: deployer has %v, want %s", got, simulated.InitialSupply)
+	}
+}
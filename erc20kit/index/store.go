@@ -0,0 +1,219 @@
+// Package index keeps a local SQLite copy of a token's Transfer and
+// Approval events, backfilled in chunks and followed live with reorgs
+// rolled back.
+package index
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Event kinds stored in the kind column.
+const (
+	KindTransfer = "transfer"
+	KindApproval = "approval"
+)
+
+// Event is a Transfer or Approval log. For approvals From is the owner and
+// To the spender.
+type Event struct {
+	Token       common.Address
+	Kind        string
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// Block is an indexed block the store remembers the hash of, to find
+// where the chain forked after a reorg.
+type Block struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS events (
+	token        TEXT    NOT NULL,
+	block_number INTEGER NOT NULL,
+	tx_hash      TEXT    NOT NULL,
+	log_index    INTEGER NOT NULL,
+	block_hash   TEXT    NOT NULL,
+	kind         TEXT    NOT NULL,
+	src          TEXT    NOT NULL,
+	dst          TEXT    NOT NULL,
+	value        TEXT    NOT NULL,
+	PRIMARY KEY (block_number, tx_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS events_src ON events (token, kind, src);
+CREATE INDEX IF NOT EXISTS events_dst ON events (token, kind, dst);
+
+CREATE TABLE IF NOT EXISTS blocks (
+	token  TEXT    NOT NULL,
+	number INTEGER NOT NULL,
+	hash   TEXT    NOT NULL,
+	PRIMARY KEY (token, number)
+);
+
+CREATE TABLE IF NOT EXISTS cursors (
+	token  TEXT    PRIMARY KEY,
+	number INTEGER NOT NULL,
+	hash   TEXT    NOT NULL
+);
+`
+
+// Store is the SQLite database behind the indexer. Addresses and hashes
+// are stored as checksummed or 0x hex, values as base 10 strings.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// one writer at a time is all SQLite does anyway
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Cursor returns the last block indexed for token. ok is false before the
+// first Commit.
+func (s *Store) Cursor(token common.Address) (cursor Block, ok bool, err error) {
+	var hash string
+	err = s.db.QueryRow(`SELECT number, hash FROM cursors WHERE token = ?`, token.Hex()).Scan(&cursor.Number, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Block{}, false, nil
+	}
+	if err != nil {
+		return Block{}, false, err
+	}
+	cursor.Hash = common.HexToHash(hash)
+	return cursor, true, nil
+}
+
+// Commit stores events and moves the cursor to the last block they were
+// looked up in, all or nothing. Events already stored are left alone.
+func (s *Store) Commit(token common.Address, events []Event, cursor Block) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO events
+			(token, block_number, tx_hash, log_index, block_hash, kind, src, dst, value)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			token.Hex(), e.BlockNumber, e.TxHash.Hex(), e.LogIndex, e.BlockHash.Hex(),
+			e.Kind, e.From.Hex(), e.To.Hex(), e.Value.String())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO blocks (token, number, hash) VALUES (?, ?, ?)`,
+			token.Hex(), e.BlockNumber, e.BlockHash.Hex())
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO blocks (token, number, hash) VALUES (?, ?, ?)`,
+		token.Hex(), cursor.Number, cursor.Hash.Hex())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO cursors (token, number, hash) VALUES (?, ?, ?)`,
+		token.Hex(), cursor.Number, cursor.Hash.Hex())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Count returns how many events of kind are stored for token.
+func (s *Store) Count(token common.Address, kind string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM events WHERE token = ? AND kind = ?`, token.Hex(), kind).Scan(&n)
+	return n, err
+}
+
+// BlocksBefore returns up to limit remembered blocks below number, newest
+// first.
+func (s *Store) BlocksBefore(token common.Address, number uint64, limit int) ([]Block, error) {
+	rows, err := s.db.Query(`SELECT number, hash FROM blocks
+		WHERE token = ? AND number < ? ORDER BY number DESC LIMIT ?`, token.Hex(), number, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []Block
+	for rows.Next() {
+		var (
+			b    Block
+			hash string
+		)
+		if err := rows.Scan(&b.Number, &hash); err != nil {
+			return nil, err
+		}
+		b.Hash = common.HexToHash(hash)
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// Rollback forgets everything above the canonical block to and makes it
+// the cursor.
+func (s *Store) Rollback(token common.Address, to Block) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM events WHERE token = ? AND block_number > ?`, token.Hex(), to.Number); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM blocks WHERE token = ? AND number > ?`, token.Hex(), to.Number); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO cursors (token, number, hash) VALUES (?, ?, ?)`,
+		token.Hex(), to.Number, to.Hash.Hex())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reset forgets everything indexed for token.
+func (s *Store) Reset(token common.Address) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"events", "blocks", "cursors"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE token = ?`, token.Hex()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
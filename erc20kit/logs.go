@@ -0,0 +1,82 @@
+package erc20kit
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// DefaultLogChunk is the widest block range asked for in one log query.
+const DefaultLogChunk = 5000
+
+// Error codes providers answer a too wide log query with: Infura's "limit
+// exceeded" and Alchemy's "invalid params".
+const (
+	rpcLimitExceeded = -32005
+	rpcInvalidParams = -32602
+)
+
+// rangeMessages are the messages of nodes and providers refusing a log
+// query for its block range or result count.
+var rangeMessages = []string{
+	"query returned more than",      // geth, Infura: "query returned more than 10000 results"
+	"exceed maximum block range",    // geth forks such as BSC: "exceed maximum block range: 5000"
+	"log response size exceeded",    // Alchemy
+	"query exceeds max results",     // Erigon
+	"query exceeds max block range", // Erigon
+	"block range is too wide",       // Ankr
+	"block range too large",         // Nethermind
+	"is limited to a",               // QuickNode: "eth_getLogs is limited to a 10,000 range"
+}
+
+// IsRangeError reports whether err is a node refusing a log query as too
+// wide, which a narrower query gets past.
+func IsRangeError(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		if code := rpcErr.ErrorCode(); code == rpcLimitExceeded || code == rpcInvalidParams {
+			return true
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeMessages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkLogRanges calls query for consecutive block ranges covering from to
+// to, at most maxChunk blocks wide. A range the node refuses as too wide is
+// halved and tried again, and the width grows back after each success. Any
+// other error from query is returned as is.
+func WalkLogRanges(ctx context.Context, from, to, maxChunk uint64, query func(from, to uint64) error) error {
+	if maxChunk == 0 {
+		maxChunk = DefaultLogChunk
+	}
+	chunk := maxChunk
+	for next := from; next <= to; {
+		end := next + chunk - 1
+		if end > to || end < next {
+			end = to
+		}
+		if err := query(next, end); err != nil {
+			if ctx.Err() == nil && chunk > 1 && IsRangeError(err) {
+				chunk /= 2
+				continue
+			}
+			return err
+		}
+		if end == to {
+			return nil
+		}
+		next = end + 1
+		if chunk *= 2; chunk > maxChunk {
+			chunk = maxChunk
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// codeError is an rpc.Error as the client returns it for an error response.
+type codeError struct {
+	code int
+	msg  string
+}
+
+func (e codeError) Error() string  { return e.msg }
+func (e codeError) ErrorCode() int { return e.code }
+
+func TestIsRangeError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("query returned more than 10000 results"), true},
+		{errors.New("exceed maximum block range: 5000"), true},
+		{errors.New("Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range"), true},
+		{fmt.Errorf("fetch logs 1-5000: %w", codeError{-32005, "limit exceeded"}), true},
+		{codeError{-32602, "invalid params"}, true},
+		{codeError{-32000, "header not found"}, false},
+		{errors.New("runtime error: index out of range"), false},
+		{errors.New("no results"), false},
+		{errors.New("connection refused"), false},
+	}
+	for _, c := range cases {
+		if got := erc20kit.IsRangeError(c.err); got != c.want {
+			t.Errorf("IsRangeError(%q) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+// TestWalkLogRanges walks a range on a node that accepts at most 3 blocks
+// per query and checks the ranges cover it without gaps.
+func TestWalkLogRanges(t *testing.T) {
+	var covered []uint64
+	err := erc20kit.WalkLogRanges(context.Background(), 10, 29, 8, func(from, to uint64) error {
+		if to-from+1 > 3 {
+			return errors.New("exceed maximum block range: 3")
+		}
+		for b := from; b <= to; b++ {
+			covered = append(covered, b)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range covered {
+		if b != uint64(10+i) {
+			t.Fatalf("covered %v, want 10 to 29 in order", covered)
+		}
+	}
+	if len(covered) != 20 {
+		t.Fatalf("covered %d blocks, want 20", len(covered))
+	}
+
+	// other errors are not retried narrower
+	calls := 0
+	failure := errors.New("connection refused")
+	err = erc20kit.WalkLogRanges(context.Background(), 0, 100, 8, func(from, to uint64) error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, failure) || calls != 1 {
+		t.Fatalf("walk with a node failure: %v after %d calls", err, calls)
+	}
+}
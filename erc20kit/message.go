@@ -0,0 +1,60 @@
+package erc20kit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// MessageSigner is a Signer that can also sign EIP-191 personal messages,
+// as personal_sign and eth_sign do. KeySigner and ClefSigner are both.
+type MessageSigner interface {
+	Signer
+	// SignMessage returns a 65 byte [R || S || V] signature of
+	// MessageHash(message), with V 27 or 28.
+	SignMessage(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// MessageHash is the EIP-191 hash of a personal message:
+// keccak256("\x19Ethereum Signed Message:\n" || len(message) || message).
+func MessageHash(message []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(message))
+}
+
+// RecoverMessage returns the address that signed message.
+func RecoverMessage(message, signature []byte) (common.Address, error) {
+	return recoverHash(MessageHash(message), signature)
+}
+
+// SignMessage signs message with the account's signer, if it can.
+func (a *Account) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	signer, ok := a.Signer.(MessageSigner)
+	if !ok {
+		return nil, fmt.Errorf("signer of %s cannot sign messages", a.Address.Hex())
+	}
+	return signer.SignMessage(ctx, message)
+}
+
+func (s *KeySigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	return s.signHash(MessageHash(message))
+}
+
+// SignMessage asks Clef to sign message with account_signData as
+// text/plain, which Clef hashes the EIP-191 way, and checks the signature
+// recovers to the account.
+func (s *ClefSigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	ctx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	var signature hexutil.Bytes
+	if err := s.client.CallContext(ctx, &signature, "account_signData", "text/plain", s.address, hexutil.Bytes(message)); err != nil {
+		return nil, fmt.Errorf("clef: sign message: %w", err)
+	}
+	if from, err := RecoverMessage(message, signature); err != nil || from != s.address {
+		return nil, fmt.Errorf("clef: message is not signed by %s", s.address.Hex())
+	}
+	return signature, nil
+}
@@ -0,0 +1,80 @@
+package erc20kit_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// mailTypedData is the example of EIP-712 as a wallet sends it, with a
+// numeric chainId.
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [{"name": "name", "type": "string"}, {"name": "wallet", "type": "address"}],
+    "Mail": [{"name": "from", "type": "Person"}, {"name": "to", "type": "Person"}, {"name": "contents", "type": "string"}]
+  },
+  "primaryType": "Mail",
+  "domain": {"name": "Ether Mail", "version": "1", "chainId": 1, "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+// TestSignMessage signs a personal message locally and through Clef and
+// recovers it.
+func TestSignMessage(t *testing.T) {
+	chain := newTestChain(t)
+	message := []byte("hello")
+	if hash := erc20kit.MessageHash(message); hash != common.HexToHash("0x50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750") {
+		t.Fatalf("message hash %s", hash.Hex())
+	}
+	signature, err := chain.User.SignMessage(chain.ctx, message)
+	if err != nil {
+		t.Fatalf("sign message: %v", err)
+	}
+	if signer, err := erc20kit.RecoverMessage(message, signature); err != nil || signer != chain.User.Address || signature[64] < 27 {
+		t.Fatalf("message signature recovers to %s (%v), v %d", signer.Hex(), err, signature[64])
+	}
+	if signer, err := erc20kit.RecoverMessage([]byte("hellO"), signature); err == nil && signer == chain.User.Address {
+		t.Fatal("signature of another message recovers to the signer")
+	}
+
+	stub, err := simulated.NewClefStub(chain.User.Signer)
+	if err != nil {
+		t.Fatalf("start clef stub: %v", err)
+	}
+	defer stub.Close()
+	clef, err := erc20kit.DialClef(chain.ctx, stub.URL, chain.User.Address)
+	if err != nil {
+		t.Fatalf("dial clef: %v", err)
+	}
+	defer clef.Close()
+	if viaClef, err := clef.SignMessage(chain.ctx, message); err != nil || string(viaClef) != string(signature) {
+		t.Errorf("sign message through clef: %x (%v), want %x", viaClef, err, signature)
+	}
+}
+
+// TestParseTypedData parses typed data from its JSON form.
+func TestParseTypedData(t *testing.T) {
+	data, err := erc20kit.ParseTypedData([]byte(mailTypedData))
+	if err != nil {
+		t.Fatalf("parse typed data: %v", err)
+	}
+	if hash, err := erc20kit.TypedDataHash(data); err != nil || hash != mailHash {
+		t.Fatalf("parsed typed data hashes to %s (%v)", hash.Hex(), err)
+	}
+	if _, err := erc20kit.ParseTypedData([]byte(`{"types": {"Mail": []}, "primaryType": "Mail"}`)); err == nil {
+		t.Error("typed data without EIP712Domain parsed")
+	}
+}
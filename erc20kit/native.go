@@ -0,0 +1,69 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MaxCost is the most tx can take from its sender in wei: the gas limit at
+// the highest price its fees allow, plus the value.
+func MaxCost(tx *types.Transaction) *big.Int {
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	return cost.Add(cost, tx.Value())
+}
+
+// Balance returns the account's balance of the native coin in wei.
+func (a *Account) Balance(ctx context.Context) (*big.Int, error) {
+	return a.backend.BalanceAt(ctx, a.Address, nil)
+}
+
+// withFundsCheck calls LowFunds before signing a transaction the balance
+// cannot pay for. Transactions sent back to back are checked one by one,
+// so their total may still exceed the balance.
+func (a *Account) withFundsCheck(ctx context.Context, signer bind.SignerFn) bind.SignerFn {
+	return func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		balance, err := a.Balance(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if maxCost := MaxCost(tx); balance.Cmp(maxCost) < 0 {
+			a.LowFunds(tx, maxCost, balance)
+		}
+		return signer(from, tx)
+	}
+}
+
+// NativeTransfer is the send function of a plain transfer of value wei
+// from from to to, for Account.Send or PrepareTx. The gas limit is
+// estimated, as a recipient contract may need more than 21000.
+func NativeTransfer(backend Backend, from, to common.Address, value *big.Int) func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	// the binding refuses to estimate a call without code, so it is
+	// estimated here and the binding only builds and signs
+	recipient := bind.NewBoundContract(to, abi.ABI{}, nil, backend, nil)
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		ctx := opts.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if opts.GasLimit == 0 {
+			gas, err := backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Value: value})
+			if err != nil {
+				return nil, err
+			}
+			opts.GasLimit = gas
+		}
+		opts.Value = value
+		return recipient.Transfer(opts)
+	}
+}
+
+// SendNative sends value wei of the native coin to to.
+func (a *Account) SendNative(ctx context.Context, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return a.Send(ctx, NativeTransfer(a.backend, a.Address, to, value))
+}
@@ -0,0 +1,60 @@
+package erc20kit_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"gb-sc-homework/erc20kit"
+)
+
+func TestMaxCost(t *testing.T) {
+	tx := types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(2), Value: big.NewInt(5)})
+	if cost := erc20kit.MaxCost(tx); cost.Cmp(big.NewInt(42005)) != 0 {
+		t.Errorf("max cost %s, want 42005", cost)
+	}
+}
+
+// TestSendNative sends coins to a fresh account and checks that an account
+// without any is warned before it signs.
+func TestSendNative(t *testing.T) {
+	chain := newTestChain(t)
+	var accounts [2]*erc20kit.Account
+	for i := range accounts {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accounts[i], err = erc20kit.NewAccount(chain.ctx, chain.Backend, erc20kit.NewKeySigner(key)); err != nil {
+			t.Fatalf("account: %v", err)
+		}
+	}
+	funded, broke := accounts[0], accounts[1]
+
+	amount := erc20kit.ToWei("1.5", 18)
+	chain.mined("send native", func() (*types.Transaction, error) {
+		return chain.Deployer.SendNative(chain.ctx, funded.Address, amount)
+	})
+	if balance, err := funded.Balance(chain.ctx); err != nil || balance.Cmp(amount) != 0 {
+		t.Fatalf("native balance %v (%v), want %s", balance, err, amount)
+	}
+
+	// a fixed gas limit, as the estimate would already fail without funds
+	broke.Auth.GasLimit = 100000
+	var warned *big.Int
+	broke.LowFunds = func(tx *types.Transaction, maxCost, balance *big.Int) {
+		warned = maxCost
+	}
+	_, err := chain.transfer(broke, chain.User.Address, big.NewInt(0))
+	if err == nil {
+		t.Fatal("account without funds sent a transaction")
+	}
+	if !erc20kit.IsRejected(err) {
+		t.Errorf("IsRejected(%v) = false", err)
+	}
+	if warned == nil || warned.Sign() <= 0 {
+		t.Error("account without funds was not warned before signing")
+	}
+}
@@ -0,0 +1,119 @@
+package erc20kit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Network is a named chain profile: where to reach it, which chain id the
+// nodes must report and how to present and price transactions on it.
+type Network struct {
+	Name    string
+	RPCURLs []string
+	ChainID *big.Int
+	// Explorer is the base URL of a block explorer, without a trailing
+	// slash. It may be empty.
+	Explorer string
+	// Currency is the symbol of the native coin that pays for gas.
+	Currency string
+	// Gas is the default gas strategy, in the syntax of ParseGasStrategy.
+	Gas string
+}
+
+// Networks are the built-in profiles by name.
+var Networks = map[string]Network{
+	"bsc": {
+		Name:     "bsc",
+		RPCURLs:  []string{"https://bsc-dataseed.binance.org/", "https://bsc-dataseed1.defibit.io/"},
+		ChainID:  big.NewInt(56),
+		Explorer: "https://bscscan.com",
+		Currency: "BNB",
+		Gas:      "legacy",
+	},
+	"bsc-testnet": {
+		Name:     "bsc-testnet",
+		RPCURLs:  []string{"https://data-seed-prebsc-1-s1.binance.org:8545/", "https://data-seed-prebsc-2-s1.binance.org:8545/"},
+		ChainID:  big.NewInt(97),
+		Explorer: "https://testnet.bscscan.com",
+		Currency: "tBNB",
+		Gas:      "legacy",
+	},
+	"ethereum": {
+		Name:     "ethereum",
+		RPCURLs:  []string{"https://cloudflare-eth.com", "https://rpc.ankr.com/eth"},
+		ChainID:  big.NewInt(1),
+		Explorer: "https://etherscan.io",
+		Currency: "ETH",
+		Gas:      "eip1559",
+	},
+	"polygon": {
+		Name:     "polygon",
+		RPCURLs:  []string{"https://polygon-rpc.com", "https://rpc.ankr.com/polygon"},
+		ChainID:  big.NewInt(137),
+		Explorer: "https://polygonscan.com",
+		Currency: "MATIC",
+		Gas:      "eip1559",
+	},
+	// a geth --dev or ganache node on the default port
+	"dev": {
+		Name:     "dev",
+		RPCURLs:  []string{"http://127.0.0.1:8545"},
+		ChainID:  big.NewInt(1337),
+		Currency: "ETH",
+		Gas:      "auto",
+	},
+}
+
+// NetworkNames lists the built-in profiles in order.
+func NetworkNames() []string {
+	names := make([]string, 0, len(Networks))
+	for name := range Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupNetwork returns a copy of a built-in profile, so callers can
+// override its RPC URLs.
+func LookupNetwork(name string) (Network, error) {
+	n, ok := Networks[strings.ToLower(name)]
+	if !ok {
+		return Network{}, fmt.Errorf("unknown network %q, want one of %s", name, strings.Join(NetworkNames(), ", "))
+	}
+	n.RPCURLs = append([]string(nil), n.RPCURLs...)
+	return n, nil
+}
+
+// ChainIDReader is the part of Backend CheckChain needs.
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// CheckChain fails with ErrWrongChain unless backend reports the chain id
+// of the profile through eth_chainId; the network id of net_version can
+// differ from it. Call it before signing anything, as the chain id is
+// what keeps a signed transaction from being valid elsewhere.
+func (n *Network) CheckChain(ctx context.Context, backend ChainIDReader) error {
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return wrap(ErrChainID, err)
+	}
+	if chainID.Cmp(n.ChainID) != 0 {
+		return wrap(ErrWrongChain, fmt.Errorf("network %s expects chain id %s, node reports %s", n.Name, n.ChainID, chainID))
+	}
+	return nil
+}
+
+// TxURL links to a transaction on the explorer, or is empty without one.
+func (n *Network) TxURL(hash common.Hash) string {
+	if n.Explorer == "" {
+		return ""
+	}
+	return n.Explorer + "/tx/" + hash.Hex()
+}
@@ -0,0 +1,31 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// TestCheckChain accepts the simulated chain as the dev profile and
+// rejects it as any other.
+func TestCheckChain(t *testing.T) {
+	ctx := context.Background()
+	chain := simulated.NewTestChain(t)
+
+	for _, name := range erc20kit.NetworkNames() {
+		network, err := erc20kit.LookupNetwork(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = network.CheckChain(ctx, chain.Backend)
+		if name == "dev" && err != nil {
+			t.Errorf("check chain as %s: %v", name, err)
+		}
+		if name != "dev" && !errors.Is(err, erc20kit.ErrWrongChain) {
+			t.Errorf("check chain as %s: got %v, want %v", name, err, erc20kit.ErrWrongChain)
+		}
+	}
+}
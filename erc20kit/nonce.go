@@ -0,0 +1,178 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NonceReader is implemented by *ethclient.Client and bind.ContractBackend.
+type NonceReader interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out sequential nonces for one address so transactions
+// can be sent back to back without waiting for each to be mined.
+//
+// Nonces are taken under a lock that is held while the transaction is
+// signed and broadcast, and are only consumed when the broadcast succeeds,
+// so a failed estimate or send never leaves a gap.
+type NonceManager struct {
+	mu      sync.Mutex
+	backend NonceReader
+	address common.Address
+	next    uint64
+	synced  bool
+}
+
+func NewNonceManager(backend NonceReader, address common.Address) *NonceManager {
+	return &NonceManager{backend: backend, address: address}
+}
+
+// Next returns the nonce the next transaction will use.
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.syncLocked(ctx, false); err != nil {
+		return 0, err
+	}
+	return m.next, nil
+}
+
+// Resync reloads the next nonce from the node's pending state.
+func (m *NonceManager) Resync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.syncLocked(ctx, true)
+}
+
+// Reset makes nonce the next one handed out, e.g. to fill a gap left by a
+// dropped transaction.
+func (m *NonceManager) Reset(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next = nonce
+	m.synced = true
+}
+
+// Use calls send with the next nonce. The nonce is consumed only if send
+// succeeds. When the node rejects it as too low, the manager resyncs and
+// tries once more; any other error also triggers a resync before the next
+// call.
+func (m *NonceManager) Use(ctx context.Context, send func(nonce uint64) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.syncLocked(ctx, false); err != nil {
+		return err
+	}
+	err := send(m.next)
+	if err != nil && isNonceTooLow(err) {
+		if err := m.syncLocked(ctx, true); err != nil {
+			return err
+		}
+		err = send(m.next)
+	}
+	if err != nil {
+		m.synced = false
+		return err
+	}
+	m.next++
+	return nil
+}
+
+// Replace calls send with a nonce that was already handed out, to speed up
+// or cancel a transaction stuck at that nonce. The sequence is left alone.
+func (m *NonceManager) Replace(nonce uint64, send func(nonce uint64) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return send(nonce)
+}
+
+func (m *NonceManager) syncLocked(ctx context.Context, force bool) error {
+	if m.synced && !force {
+		return nil
+	}
+	nonce, err := m.backend.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return wrap(ErrNonce, err)
+	}
+	m.next = nonce
+	m.synced = true
+	return nil
+}
+
+func isNonceTooLow(err error) bool {
+	msg := err.Error()
+	// "nonce too low" from geth style nodes, "invalid transaction nonce"
+	// from the simulated backend
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "invalid transaction nonce")
+}
+
+// Send calls send with a copy of Auth carrying the next nonce from the
+// account's NonceManager and fees from its GasStrategy, e.g.
+//
+//	tx, err := account.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+//		return instance.Transfer(opts, to, amount)
+//	})
+func (a *Account) Send(ctx context.Context, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := a.Nonces.Use(ctx, func(nonce uint64) error {
+		opts, err := a.TransactOpts(ctx, nonce)
+		if err != nil {
+			return err
+		}
+		tx, err = send(opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SendWithNonce is Send for an explicit, already used nonce. It is meant for
+// replacing a stuck transaction.
+func (a *Account) SendWithNonce(ctx context.Context, nonce uint64, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	var tx *types.Transaction
+	err := a.Nonces.Replace(nonce, func(nonce uint64) error {
+		opts, err := a.TransactOpts(ctx, nonce)
+		if err != nil {
+			return err
+		}
+		tx, err = send(opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// TransactOpts returns a copy of Auth for one transaction at nonce, with
+// fees from Gas and the gas limit margin applied, that signs within ctx.
+func (a *Account) TransactOpts(ctx context.Context, nonce uint64) (*bind.TransactOpts, error) {
+	opts := *a.Auth
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.Context = ctx
+	opts.Signer = a.signerFn(ctx)
+	if a.Gas != nil {
+		fees, err := a.Gas.Fees(ctx, a.backend)
+		if err != nil {
+			return nil, err
+		}
+		applyFees(&opts, fees)
+	}
+	if a.LowFunds != nil {
+		opts.Signer = a.withFundsCheck(ctx, opts.Signer)
+	}
+	if opts.GasLimit == 0 && a.GasLimitMargin > 0 {
+		opts.Signer = withGasMargin(opts.Signer, a.GasLimitMargin)
+	}
+	return &opts, nil
+}
@@ -0,0 +1,46 @@
+package erc20kit_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestPipelinedSends sends transfers from several goroutines without
+// waiting in between and checks that no nonce is used twice.
+func TestPipelinedSends(t *testing.T) {
+	chain := newTestChain(t)
+	const n = 10
+
+	txs := make([]*types.Transaction, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txs[i], errs[i] = chain.transfer(chain.Deployer, chain.User.Address, wei("1"))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]bool)
+	for i, tx := range txs {
+		if errs[i] != nil {
+			t.Fatalf("pipelined transfer %d: %v", i, errs[i])
+		}
+		if seen[tx.Nonce()] {
+			t.Fatalf("pipelined transfer %d reused nonce %d", i, tx.Nonce())
+		}
+		seen[tx.Nonce()] = true
+	}
+	for i, tx := range txs {
+		tx := tx
+		chain.mined(fmt.Sprintf("pipelined transfer %d (nonce %d)", i, tx.Nonce()), func() (*types.Transaction, error) {
+			return tx, nil
+		})
+	}
+	chain.expectBalance("user", chain.User.Address, wei(fmt.Sprint(n)))
+}
@@ -0,0 +1,140 @@
+package erc20kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// UnsignedTx is a transaction prepared on a machine with node access, to
+// be signed on one without. Tx holds everything signing needs, including
+// the chain id.
+type UnsignedTx struct {
+	Description string              `json:"description,omitempty"`
+	Tx          apitypes.SendTxArgs `json:"tx"`
+}
+
+// SignedTx is an UnsignedTx after signing, ready to broadcast.
+type SignedTx struct {
+	Description string        `json:"description,omitempty"`
+	Hash        common.Hash   `json:"hash"`
+	Raw         hexutil.Bytes `json:"raw"`
+}
+
+// PrepareOptions control PrepareTx.
+type PrepareOptions struct {
+	// Gas prices the transaction. Nil leaves the fees to the binding.
+	Gas GasStrategy
+	// GasLimitMargin is the percentage added to the estimated gas limit.
+	GasLimitMargin uint64
+	// Nonce overrides the node's pending nonce of the sender, e.g. to
+	// prepare several transactions in a row.
+	Nonce *uint64
+}
+
+// PrepareTx builds the transaction send makes for from without signing or
+// sending it. The nonce, fees, gas limit and chain id come from the node,
+// so from needs no key here, e.g.
+//
+//	unsigned, err := PrepareTx(ctx, backend, owner, opts, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+//		return instance.Approve(opts, spender, amount)
+//	})
+func PrepareTx(ctx context.Context, backend Backend, from common.Address, options PrepareOptions, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*UnsignedTx, error) {
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, wrap(ErrChainID, err)
+	}
+	var nonce uint64
+	if options.Nonce != nil {
+		nonce = *options.Nonce
+	} else if nonce, err = backend.PendingNonceAt(ctx, from); err != nil {
+		return nil, wrap(ErrNonce, err)
+	}
+
+	opts := &bind.TransactOpts{
+		From:    from,
+		Nonce:   new(big.Int).SetUint64(nonce),
+		Value:   big.NewInt(0),
+		Context: ctx,
+		NoSend:  true,
+		// hands the transaction back as the binding built it
+		Signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+	}
+	if options.Gas != nil {
+		fees, err := options.Gas.Fees(ctx, backend)
+		if err != nil {
+			return nil, err
+		}
+		applyFees(opts, fees)
+	}
+	if options.GasLimitMargin > 0 {
+		opts.Signer = withGasMargin(opts.Signer, options.GasLimitMargin)
+	}
+	tx, err := send(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &UnsignedTx{Tx: NewClefTxArgs(from, tx, chainID)}, nil
+}
+
+// Sign signs the transaction with signer, which must hold the key of the
+// sender it was prepared for.
+func (u *UnsignedTx) Sign(ctx context.Context, signer Signer) (*SignedTx, error) {
+	if from := u.Tx.From.Address(); signer.Address() != from {
+		return nil, fmt.Errorf("transaction is from %s, signer is %s", from.Hex(), signer.Address().Hex())
+	}
+	if u.Tx.ChainID == nil {
+		return nil, errors.New("transaction has no chain id")
+	}
+	if u.Tx.GasPrice == nil && u.Tx.MaxFeePerGas == nil {
+		return nil, errors.New("transaction has no fees")
+	}
+	signed, err := signer.SignTx(ctx, u.Tx.ToTransaction(), u.Tx.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTx{Description: u.Description, Hash: signed.Hash(), Raw: raw}, nil
+}
+
+// Transaction decodes the signed transaction and checks it against Hash.
+func (s *SignedTx) Transaction() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(s.Raw); err != nil {
+		return nil, err
+	}
+	if tx.Hash() != s.Hash {
+		return nil, fmt.Errorf("raw transaction hashes to %s, not %s", tx.Hash().Hex(), s.Hash.Hex())
+	}
+	return tx, nil
+}
+
+// DecodeCall formats calldata as method(arg: value, ...) with the methods
+// of contract, or returns "" if none matches.
+func DecodeCall(data []byte, contract *abi.ABI) string {
+	if len(data) < 4 {
+		return ""
+	}
+	method, err := contract.MethodById(data[:4])
+	if err != nil {
+		return ""
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return ""
+	}
+	return method.Name + formatArgs(method.Inputs, values)
+}
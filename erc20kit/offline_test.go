@@ -0,0 +1,87 @@
+package erc20kit_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// TestOfflineSigning prepares a transfer without a key, signs it from its
+// JSON form and broadcasts the signed JSON.
+func TestOfflineSigning(t *testing.T) {
+	chain := newTestChain(t)
+	amount := wei("3")
+	options := erc20kit.PrepareOptions{Gas: erc20kit.AutoGas{}, GasLimitMargin: erc20kit.DefaultGasLimitMargin}
+	prepared, err := erc20kit.PrepareTx(chain.ctx, chain.Backend, chain.Deployer.Address, options, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return chain.Instance.Transfer(opts, chain.User.Address, amount)
+	})
+	if err != nil {
+		t.Fatalf("prepare transfer: %v", err)
+	}
+	parsed, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("transfer(to: %s, amount: %s)", chain.User.Address.Hex(), amount)
+	if call := erc20kit.DecodeCall(*prepared.Tx.Data, parsed); call != want {
+		t.Fatalf("decoded call = %q, want %q", call, want)
+	}
+
+	var unsigned erc20kit.UnsignedTx
+	roundTrip(t, prepared, &unsigned)
+	if _, err := unsigned.Sign(chain.ctx, chain.User.Signer); err == nil {
+		t.Fatal("signed the deployer's transaction with the user's key")
+	}
+	signed, err := unsigned.Sign(chain.ctx, chain.Deployer.Signer)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	var decoded erc20kit.SignedTx
+	roundTrip(t, signed, &decoded)
+	tx, err := decoded.Transaction()
+	if err != nil {
+		t.Fatalf("decode signed tx: %v", err)
+	}
+	chain.mined("offline transfer", func() (*types.Transaction, error) {
+		return tx, chain.Backend.SendTransaction(chain.ctx, tx)
+	})
+	chain.expectBalance("user", chain.User.Address, amount)
+}
+
+// TestChainIDOverNetworkID signs for eth_chainId when net_version differs.
+func TestChainIDOverNetworkID(t *testing.T) {
+	chain := newTestChain(t)
+	backend := netVersionBackend{chain.Backend}
+	want, err := chain.Backend.ChainID(chain.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prepared, err := erc20kit.PrepareTx(chain.ctx, backend, chain.Deployer.Address, erc20kit.PrepareOptions{Gas: erc20kit.AutoGas{}}, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return chain.Instance.Transfer(opts, chain.User.Address, wei("1"))
+	})
+	if err != nil {
+		t.Fatalf("prepare transfer: %v", err)
+	}
+	if id := prepared.Tx.ChainID.ToInt(); id.Cmp(want) != 0 {
+		t.Fatalf("prepared chain id = %s, want %s", id, want)
+	}
+
+	account, err := erc20kit.NewAccount(chain.ctx, backend, chain.User.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := account.Auth.Signer(account.Address, types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), Gas: 21000}))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if id := tx.ChainId(); id.Cmp(want) != 0 {
+		t.Fatalf("signed chain id = %s, want %s", id, want)
+	}
+}
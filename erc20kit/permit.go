@@ -0,0 +1,211 @@
+package erc20kit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	token "gb-sc-homework/contracts/IERC20"
+)
+
+// permitVersions are the domain versions tried when rebuilding the
+// domain separator of a token: OpenZeppelin's ERC20Permit uses "1", USDC
+// "2".
+var permitVersions = []string{"1", "2"}
+
+var permitTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	"Permit": {
+		{Name: "owner", Type: "address"},
+		{Name: "spender", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	},
+}
+
+// Permit is an EIP-2612 approval signed off-chain by Owner. Signing costs
+// the owner no gas; whoever submits it pays, usually the spender.
+type Permit struct {
+	Domain    apitypes.TypedDataDomain `json:"domain"`
+	Owner     common.Address           `json:"owner"`
+	Spender   common.Address           `json:"spender"`
+	Value     *hexutil.Big             `json:"value"`
+	Nonce     *hexutil.Big             `json:"nonce"`
+	Deadline  *hexutil.Big             `json:"deadline"`
+	Signature hexutil.Bytes            `json:"signature"`
+}
+
+// PermitDomain rebuilds the EIP-712 domain of an EIP-2612 token and checks
+// it against the token's DOMAIN_SEPARATOR. It returns ErrNoPermit when the
+// token has no permit or a domain it cannot reproduce; approve is the way
+// to go then.
+func PermitDomain(ctx context.Context, backend Backend, tokenAddress common.Address) (apitypes.TypedDataDomain, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	permit, err := token.NewIERC20PermitCaller(tokenAddress, backend)
+	if err != nil {
+		return apitypes.TypedDataDomain{}, err
+	}
+	separator, err := permit.DOMAINSEPARATOR(opts)
+	if err != nil {
+		return apitypes.TypedDataDomain{}, wrap(ErrNoPermit, err)
+	}
+	if _, err := permit.Nonces(opts, common.Address{}); err != nil {
+		return apitypes.TypedDataDomain{}, wrap(ErrNoPermit, err)
+	}
+	erc20, err := token.NewERC20tokenCaller(tokenAddress, backend)
+	if err != nil {
+		return apitypes.TypedDataDomain{}, err
+	}
+	name, err := erc20.Name(opts)
+	if err != nil {
+		return apitypes.TypedDataDomain{}, wrap(ErrNoPermit, err)
+	}
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return apitypes.TypedDataDomain{}, wrap(ErrChainID, err)
+	}
+
+	for _, version := range permitVersions {
+		domain := apitypes.TypedDataDomain{
+			Name:              name,
+			Version:           version,
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: tokenAddress.Hex(),
+		}
+		data := apitypes.TypedData{Types: permitTypes, Domain: domain}
+		hash, err := data.HashStruct("EIP712Domain", domain.Map())
+		if err != nil {
+			return apitypes.TypedDataDomain{}, err
+		}
+		if common.BytesToHash(hash) == common.Hash(separator) {
+			return domain, nil
+		}
+	}
+	return apitypes.TypedDataDomain{}, wrap(ErrNoPermit, fmt.Errorf("domain separator %s matches no known domain", common.Hash(separator).Hex()))
+}
+
+// SignPermit signs a permit letting spender move value of the account's
+// tokens until deadline. Tokens without permit return ErrNoPermit.
+func (a *Account) SignPermit(ctx context.Context, tokenAddress, spender common.Address, value *big.Int, deadline time.Time) (*Permit, error) {
+	domain, err := PermitDomain(ctx, a.backend, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	permit, err := token.NewIERC20PermitCaller(tokenAddress, a.backend)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := permit.Nonces(&bind.CallOpts{Context: ctx}, a.Address)
+	if err != nil {
+		return nil, wrap(ErrNoPermit, err)
+	}
+	p := &Permit{
+		Domain:   domain,
+		Owner:    a.Address,
+		Spender:  spender,
+		Value:    (*hexutil.Big)(value),
+		Nonce:    (*hexutil.Big)(nonce),
+		Deadline: (*hexutil.Big)(big.NewInt(deadline.Unix())),
+	}
+	if p.Signature, err = a.SignTypedData(ctx, p.TypedData()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SubmitPermit sends p to its token from the account. The permit is
+// checked first: a bad signature would only be found by the revert.
+func (a *Account) SubmitPermit(ctx context.Context, p *Permit) (*types.Transaction, error) {
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	permit, err := token.NewIERC20PermitTransactor(p.Token(), a.backend)
+	if err != nil {
+		return nil, err
+	}
+	v, r, s := p.VRS()
+	return a.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return permit.Permit(opts, p.Owner, p.Spender, p.Value.ToInt(), p.Deadline.ToInt(), v, r, s)
+	})
+}
+
+// Token is the token the permit is for.
+func (p *Permit) Token() common.Address {
+	return common.HexToAddress(p.Domain.VerifyingContract)
+}
+
+// TypedData is the EIP-712 message the owner signs.
+func (p *Permit) TypedData() apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       permitTypes,
+		PrimaryType: "Permit",
+		Domain:      p.Domain,
+		Message: apitypes.TypedDataMessage{
+			"owner":    p.Owner.Hex(),
+			"spender":  p.Spender.Hex(),
+			"value":    p.Value.ToInt().String(),
+			"nonce":    p.Nonce.ToInt().String(),
+			"deadline": p.Deadline.ToInt().String(),
+		},
+	}
+}
+
+// Verify checks that Owner signed the permit.
+func (p *Permit) Verify() error {
+	signer, err := RecoverTypedData(p.TypedData(), p.Signature)
+	if err != nil {
+		return fmt.Errorf("permit signature: %w", err)
+	}
+	if signer != p.Owner {
+		return wrap(ErrBadSigner, fmt.Errorf("permit is signed by %s, not by the owner %s", signer.Hex(), p.Owner.Hex()))
+	}
+	return nil
+}
+
+// Expired reports whether the deadline has passed at now.
+func (p *Permit) Expired(now time.Time) bool {
+	return p.Deadline.ToInt().Cmp(big.NewInt(now.Unix())) < 0
+}
+
+// Used reports whether the token has already consumed the permit's nonce,
+// because the permit or a later one of the owner was submitted.
+func (p *Permit) Used(ctx context.Context, backend Backend) (bool, error) {
+	permit, err := token.NewIERC20PermitCaller(p.Token(), backend)
+	if err != nil {
+		return false, err
+	}
+	nonce, err := permit.Nonces(&bind.CallOpts{Context: ctx}, p.Owner)
+	if err != nil {
+		return false, err
+	}
+	return nonce.Cmp(p.Nonce.ToInt()) > 0, nil
+}
+
+// VRS splits the signature into the v, r and s arguments of permit().
+func (p *Permit) VRS() (v uint8, r, s [32]byte) {
+	if len(p.Signature) != crypto.SignatureLength {
+		return 0, r, s
+	}
+	copy(r[:], p.Signature[:32])
+	copy(s[:], p.Signature[32:64])
+	v = p.Signature[crypto.RecoveryIDOffset]
+	if v < 27 {
+		v += 27
+	}
+	return v, r, s
+}
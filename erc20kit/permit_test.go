@@ -0,0 +1,216 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// mail is the example of EIP-712, mailHash its hash as given by the EIP.
+var (
+	mail = apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": {{Name: "name", Type: "string"}, {Name: "wallet", Type: "address"}},
+			"Mail":   {{Name: "from", Type: "Person"}, {Name: "to", Type: "Person"}, {Name: "contents", Type: "string"}},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+		},
+		Message: apitypes.TypedDataMessage{
+			"from":     map[string]interface{}{"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to":       map[string]interface{}{"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!",
+		},
+	}
+	mailHash = common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2")
+)
+
+// TestSignTypedData checks EIP-712 signing against the Mail example of the
+// EIP, locally and through Clef.
+func TestSignTypedData(t *testing.T) {
+	hash, err := erc20kit.TypedDataHash(mail)
+	if err != nil {
+		t.Fatalf("hash typed data: %v", err)
+	}
+	if hash != mailHash {
+		t.Fatalf("typed data hash %s, want %s", hash.Hex(), mailHash.Hex())
+	}
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cow := erc20kit.NewKeySigner(key)
+	if cow.Address() != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Fatalf("key of cow is %s", cow.Address().Hex())
+	}
+	signature, err := cow.SignTypedData(context.Background(), mail)
+	if err != nil {
+		t.Fatalf("sign typed data: %v", err)
+	}
+	if signer, err := erc20kit.RecoverTypedData(mail, signature); err != nil || signer != cow.Address() || signature[64] < 27 {
+		t.Fatalf("typed data signature recovers to %s (%v), v %d", signer.Hex(), err, signature[64])
+	}
+
+	stub, err := simulated.NewClefStub(cow)
+	if err != nil {
+		t.Fatalf("start clef stub: %v", err)
+	}
+	defer stub.Close()
+	clef, err := erc20kit.DialClef(context.Background(), stub.URL, cow.Address())
+	if err != nil {
+		t.Fatalf("dial clef: %v", err)
+	}
+	defer clef.Close()
+	if viaClef, err := clef.SignTypedData(context.Background(), mail); err != nil || string(viaClef) != string(signature) {
+		t.Errorf("sign typed data through clef: %x (%v), want %x", viaClef, err, signature)
+	}
+}
+
+// TestPermit checks that the reference token, which has no permit, is
+// detected as such and that a permit survives the trip through its JSON
+// file.
+func TestPermit(t *testing.T) {
+	chain := newTestChain(t)
+	if _, err := erc20kit.PermitDomain(chain.ctx, chain.Backend, chain.Token); !errors.Is(err, erc20kit.ErrNoPermit) {
+		t.Fatalf("permit domain of the reference token: want ErrNoPermit, got %v", err)
+	}
+	if _, err := chain.User.SignPermit(chain.ctx, chain.Token, chain.Deployer.Address, wei("1"), time.Now().Add(time.Hour)); !errors.Is(err, erc20kit.ErrNoPermit) {
+		t.Fatalf("sign permit for the reference token: want ErrNoPermit, got %v", err)
+	}
+
+	permit := &erc20kit.Permit{
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Reference",
+			Version:           "1",
+			ChainId:           (*math.HexOrDecimal256)(big.NewInt(1337)),
+			VerifyingContract: chain.Token.Hex(),
+		},
+		Owner:    chain.User.Address,
+		Spender:  chain.Deployer.Address,
+		Value:    (*hexutil.Big)(wei("1")),
+		Nonce:    (*hexutil.Big)(big.NewInt(0)),
+		Deadline: (*hexutil.Big)(big.NewInt(time.Now().Add(time.Hour).Unix())),
+	}
+	var err error
+	if permit.Signature, err = chain.User.SignTypedData(chain.ctx, permit.TypedData()); err != nil {
+		t.Fatalf("sign permit: %v", err)
+	}
+	var received erc20kit.Permit
+	roundTrip(t, permit, &received)
+	if err := received.Verify(); err != nil || received.Token() != chain.Token || received.Expired(time.Now()) {
+		t.Fatalf("permit after json: %v, token %s", err, received.Token().Hex())
+	}
+	if v, _, _ := received.VRS(); v != 27 && v != 28 {
+		t.Fatalf("permit v is %d", v)
+	}
+	received.Value = (*hexutil.Big)(wei("1000"))
+	if err := received.Verify(); err == nil {
+		t.Error("permit with a raised value still verifies")
+	}
+}
+
+// permitBackend answers the calls PermitDomain makes to a permit token at
+// address with a domain separator built for chainID, the block.chainid of
+// the token. Its net_version differs.
+type permitBackend struct {
+	netVersionBackend
+	address   common.Address
+	separator common.Hash
+}
+
+func newPermitBackend(t *testing.T, backend *simulated.Backend, address common.Address, name string, chainID *big.Int) *permitBackend {
+	t.Helper()
+	domain := apitypes.TypedDataDomain{
+		Name:              name,
+		Version:           "1",
+		ChainId:           (*math.HexOrDecimal256)(chainID),
+		VerifyingContract: address.Hex(),
+	}
+	data := apitypes.TypedData{Types: apitypes.Types{"EIP712Domain": mail.Types["EIP712Domain"]}, Domain: domain}
+	separator, err := data.HashStruct("EIP712Domain", domain.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &permitBackend{netVersionBackend: netVersionBackend{backend}, address: address, separator: common.BytesToHash(separator)}
+}
+
+func (b *permitBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if call.To == nil || *call.To != b.address {
+		return b.netVersionBackend.CallContract(ctx, call, blockNumber)
+	}
+	permitABI, err := token.IERC20PermitMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	erc20ABI, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if method, err := permitABI.MethodById(call.Data); err == nil {
+		switch method.Name {
+		case "DOMAIN_SEPARATOR":
+			return method.Outputs.Pack(b.separator)
+		case "nonces":
+			return method.Outputs.Pack(big.NewInt(0))
+		}
+	}
+	if method, err := erc20ABI.MethodById(call.Data); err == nil && method.Name == "name" {
+		return method.Outputs.Pack("Permit Token")
+	}
+	return nil, errors.New("execution reverted")
+}
+
+// TestPermitDomainChainID rebuilds the domain of a permit token on a node
+// whose net_version differs from eth_chainId.
+func TestPermitDomainChainID(t *testing.T) {
+	chain := newTestChain(t)
+	chainID, err := chain.Backend.ChainID(chain.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := common.HexToAddress("0x000000000000000000000000000000000000bEEF")
+	backend := newPermitBackend(t, chain.Backend, address, "Permit Token", chainID)
+
+	domain, err := erc20kit.PermitDomain(chain.ctx, backend, address)
+	if err != nil {
+		t.Fatalf("permit domain: %v", err)
+	}
+	if id := (*big.Int)(domain.ChainId); id.Cmp(chainID) != 0 || domain.Name != "Permit Token" || domain.Version != "1" {
+		t.Fatalf("permit domain %+v, chain id %s", domain, id)
+	}
+
+	account, err := erc20kit.NewAccount(chain.ctx, backend, chain.User.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permit, err := account.SignPermit(chain.ctx, address, chain.Deployer.Address, wei("1"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("sign permit: %v", err)
+	}
+	if err := permit.Verify(); err != nil {
+		t.Fatalf("verify permit: %v", err)
+	}
+}
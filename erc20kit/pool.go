@@ -0,0 +1,487 @@
+package erc20kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Pool defaults.
+const (
+	DefaultCallTimeout    = 10 * time.Second
+	DefaultPoolRetries    = 2
+	DefaultPoolBackoff    = 500 * time.Millisecond
+	DefaultMaxLag         = 3
+	DefaultHealthInterval = 30 * time.Second
+)
+
+// PoolOptions control a Pool. Zero values take the defaults above.
+type PoolOptions struct {
+	// CallTimeout bounds one attempt on one node. A node that runs over is
+	// treated like one that is down.
+	CallTimeout time.Duration
+	// Retries is how many more rounds over all nodes a call gets after
+	// every node failed with a transient error.
+	Retries int
+	// Backoff is the pause before the first retry round. It doubles every
+	// round, and is also how long a failing node is avoided, doubling
+	// with each failure in a row up to a minute.
+	Backoff time.Duration
+	// MaxLag is how many blocks a node may trail the highest known head
+	// and still serve reads first.
+	MaxLag uint64
+	// HealthInterval is how often every node is asked for its head.
+	HealthInterval time.Duration
+}
+
+// Pool is a Backend over several RPC endpoints of one chain.
+//
+// Calls go to the preferred node first: the fastest of the nodes that
+// answer and are within MaxLag blocks of the highest head. It stays
+// preferred until it fails or falls behind, so that consecutive reads such
+// as those of WaitForReceipt see one node's view of the chain. Timeouts,
+// rate limits, 5xx responses and connection errors move the call on to the
+// next node and, once every node failed, to another round after Backoff.
+// Any other error, such as a revert or ethereum.NotFound, is the node's
+// answer and is returned as is.
+type Pool struct {
+	opts  PoolOptions
+	nodes []*poolNode
+
+	mu        sync.Mutex
+	preferred *poolNode
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type poolNode struct {
+	// name is the host of the endpoint, so API keys in the path or query
+	// stay out of error messages.
+	name   string
+	client *ethclient.Client
+
+	// guarded by Pool.mu
+	latency   time.Duration
+	head      uint64
+	failures  int
+	downUntil time.Time
+}
+
+var _ Backend = (*Pool)(nil)
+
+// DialPool connects to every endpoint, checks their heads once and keeps
+// checking them in the background until Close. Endpoints that cannot be
+// dialed are an error; endpoints that do not answer yet are only avoided.
+func DialPool(ctx context.Context, urls []string, opts PoolOptions) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, wrap(ErrDial, errors.New("no rpc endpoints"))
+	}
+	if opts.CallTimeout <= 0 {
+		opts.CallTimeout = DefaultCallTimeout
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultPoolRetries
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultPoolBackoff
+	}
+	if opts.MaxLag == 0 {
+		opts.MaxLag = DefaultMaxLag
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = DefaultHealthInterval
+	}
+
+	p := &Pool{opts: opts, stop: make(chan struct{}), done: make(chan struct{})}
+	for _, rawurl := range urls {
+		client, err := ethclient.DialContext(ctx, rawurl)
+		if err != nil {
+			p.closeClients()
+			return nil, wrap(ErrDial, fmt.Errorf("%s: %w", endpointName(rawurl), err))
+		}
+		p.nodes = append(p.nodes, &poolNode{name: endpointName(rawurl), client: client})
+	}
+
+	p.checkHealth(ctx)
+	go p.healthLoop()
+	return p, nil
+}
+
+func endpointName(rawurl string) string {
+	if u, err := url.Parse(rawurl); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawurl
+}
+
+// Close stops the health checks and disconnects from every node.
+func (p *Pool) Close() {
+	close(p.stop)
+	<-p.done
+	p.closeClients()
+}
+
+func (p *Pool) closeClients() {
+	for _, n := range p.nodes {
+		n.client.Close()
+	}
+}
+
+func (p *Pool) healthLoop() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkHealth(context.Background())
+		}
+	}
+}
+
+// checkHealth asks every node for its head at once.
+func (p *Pool) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, n := range p.nodes {
+		wg.Add(1)
+		go func(n *poolNode) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, p.opts.CallTimeout)
+			defer cancel()
+			start := time.Now()
+			head, err := n.client.BlockNumber(ctx)
+			if err != nil {
+				p.failed(n)
+				return
+			}
+			p.succeeded(n, time.Since(start))
+			p.mu.Lock()
+			n.head = head
+			p.mu.Unlock()
+		}(n)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) succeeded(n *poolNode, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.failures = 0
+	n.downUntil = time.Time{}
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		n.latency = (3*n.latency + latency) / 4
+	}
+}
+
+func (p *Pool) failed(n *poolNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.failures++
+	avoid := p.opts.Backoff << uint(n.failures-1)
+	if avoid <= 0 || avoid > time.Minute {
+		avoid = time.Minute
+	}
+	n.downUntil = time.Now().Add(avoid)
+}
+
+// ranked orders the nodes for the next call: the preferred node, then the
+// other nodes that are up and in sync by latency, then the lagging and the
+// failing ones. Failing nodes are still tried last rather than skipped.
+func (p *Pool) ranked() []*poolNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var best uint64
+	for _, n := range p.nodes {
+		if n.head > best {
+			best = n.head
+		}
+	}
+	class := func(n *poolNode) int {
+		switch {
+		case now.Before(n.downUntil):
+			return 2
+		case n.head+p.opts.MaxLag < best:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	nodes := append([]*poolNode(nil), p.nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		ci, cj := class(nodes[i]), class(nodes[j])
+		if ci != cj {
+			return ci < cj
+		}
+		return nodes[i].latency < nodes[j].latency
+	})
+	if p.preferred == nil || class(p.preferred) != 0 {
+		p.preferred = nodes[0]
+	}
+	for i, n := range nodes {
+		if n == p.preferred {
+			copy(nodes[1:i+1], nodes[:i])
+			nodes[0] = n
+			break
+		}
+	}
+	return nodes
+}
+
+// do runs call on the nodes in ranked order until one answers.
+func (p *Pool) do(ctx context.Context, call func(ctx context.Context, client *ethclient.Client) error) error {
+	backoff := p.opts.Backoff
+	for round := 0; ; round++ {
+		var errs []string
+		for _, n := range p.ranked() {
+			callCtx, cancel := context.WithTimeout(ctx, p.opts.CallTimeout)
+			start := time.Now()
+			err := call(callCtx, n.client)
+			cancel()
+			if err == nil || !isTransient(err) && ctx.Err() == nil {
+				p.succeeded(n, time.Since(start))
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			p.failed(n)
+			errs = append(errs, fmt.Sprintf("%s: %v", n.name, err))
+		}
+		if round >= p.opts.Retries {
+			return fmt.Errorf("all rpc endpoints failed: %s", strings.Join(errs, "; "))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransient reports whether err says more about the node than about the
+// request, so that another node or a later attempt may well succeed.
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429 || httpErr.StatusCode >= 500
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// -32005 is the de facto "limit exceeded" code of hosted nodes
+		return rpcErr.ErrorCode() == -32005
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "too many requests") || strings.Contains(msg, "rate limit")
+}
+
+// isAlreadyKnown matches the errors nodes give for a transaction that is
+// already in their pool.
+func isAlreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// subscribe opens a subscription on the first node in ranked order that
+// supports them. It is not retried: callers resubscribe when it drops.
+func (p *Pool) subscribe(ctx context.Context, open func(client *ethclient.Client) (ethereum.Subscription, error)) (ethereum.Subscription, error) {
+	var err error
+	for _, n := range p.ranked() {
+		var sub ethereum.Subscription
+		if sub, err = open(n.client); err == nil {
+			return sub, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			p.failed(n)
+		}
+	}
+	return nil, err
+}
+
+func (p *Pool) NetworkID(ctx context.Context) (id *big.Int, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		id, err = c.NetworkID(ctx)
+		return err
+	})
+	return id, err
+}
+
+func (p *Pool) ChainID(ctx context.Context) (id *big.Int, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		id, err = c.ChainID(ctx)
+		return err
+	})
+	return id, err
+}
+
+func (p *Pool) BlockNumber(ctx context.Context) (number uint64, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		number, err = c.BlockNumber(ctx)
+		return err
+	})
+	return number, err
+}
+
+func (p *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		header, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (p *Pool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		balance, err = c.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (p *Pool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		code, err = c.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (p *Pool) PendingCodeAt(ctx context.Context, contract common.Address) (code []byte, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		code, err = c.PendingCodeAt(ctx, contract)
+		return err
+	})
+	return code, err
+}
+
+func (p *Pool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (output []byte, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		output, err = c.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return output, err
+}
+
+func (p *Pool) PendingCallContract(ctx context.Context, call ethereum.CallMsg) (output []byte, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		output, err = c.PendingCallContract(ctx, call)
+		return err
+	})
+	return output, err
+}
+
+func (p *Pool) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		nonce, err = c.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (p *Pool) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		price, err = c.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (p *Pool) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		tip, err = c.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+func (p *Pool) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		gas, err = c.EstimateGas(ctx, call)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction broadcasts tx like any other call. A node that reports
+// tx as already known after an earlier attempt timed out counts as success.
+func (p *Pool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	attempted := false
+	return p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		err := c.SendTransaction(ctx, tx)
+		if err != nil && attempted && isAlreadyKnown(err) {
+			return nil
+		}
+		attempted = true
+		return err
+	})
+}
+
+func (p *Pool) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		tx, isPending, err = c.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (p *Pool) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		receipt, err = c.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+func (p *Pool) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = p.do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		logs, err = c.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+func (p *Pool) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return p.subscribe(ctx, func(c *ethclient.Client) (ethereum.Subscription, error) {
+		return c.SubscribeFilterLogs(ctx, query, ch)
+	})
+}
+
+func (p *Pool) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return p.subscribe(ctx, func(c *ethclient.Client) (ethereum.Subscription, error) {
+		return c.SubscribeNewHead(ctx, ch)
+	})
+}
@@ -0,0 +1,53 @@
+package erc20kit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// TestPoolFailover answers through a healthy node while another one
+// rate-limits every request.
+func TestPoolFailover(t *testing.T) {
+	ctx := context.Background()
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		results := map[string]string{"net_version": "1337", "eth_chainId": "0x539", "eth_blockNumber": "0x10"}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": results[req.Method]})
+	}))
+	defer healthy.Close()
+
+	pool, err := erc20kit.DialPool(ctx, []string{limited.URL, healthy.URL}, erc20kit.PoolOptions{Backoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("dial pool: %v", err)
+	}
+	defer pool.Close()
+	for i := 0; i < 3; i++ {
+		id, err := pool.NetworkID(ctx)
+		if err != nil || id.Int64() != 1337 {
+			t.Fatalf("network id = %v, %v; want 1337", id, err)
+		}
+		if id, err := pool.ChainID(ctx); err != nil || id.Int64() != 1337 {
+			t.Fatalf("chain id = %v, %v; want 1337", id, err)
+		}
+	}
+
+	healthy.Close()
+	if _, err := pool.BlockNumber(ctx); err == nil {
+		t.Error("pool answered with every node down")
+	}
+}
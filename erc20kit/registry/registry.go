@@ -0,0 +1,162 @@
+// Package registry is a file of named tokens per chain with their
+// metadata cached, so commands can take a symbol instead of an address and
+// skip reading decimals on every run.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	token "gb-sc-homework/contracts/IERC20"
+)
+
+// Token is the cached metadata of one token. TotalSupply, in base units,
+// is as of UpdatedAt.
+type Token struct {
+	Address     common.Address `json:"address"`
+	Name        string         `json:"name"`
+	Symbol      string         `json:"symbol"`
+	Decimals    uint8          `json:"decimals"`
+	TotalSupply string         `json:"totalSupply,omitempty"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// Fetch reads a token's metadata from the chain.
+func Fetch(ctx context.Context, caller bind.ContractCaller, address common.Address) (*Token, error) {
+	instance, err := token.NewERC20tokenCaller(address, caller)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	decimals, err := instance.Decimals(opts)
+	if err != nil {
+		return nil, fmt.Errorf("read decimals of %s: %w", address.Hex(), err)
+	}
+	name, err := instance.Name(opts)
+	if err != nil {
+		return nil, fmt.Errorf("read name of %s: %w", address.Hex(), err)
+	}
+	symbol, err := instance.Symbol(opts)
+	if err != nil {
+		return nil, fmt.Errorf("read symbol of %s: %w", address.Hex(), err)
+	}
+	totalSupply, err := instance.TotalSupply(opts)
+	if err != nil {
+		return nil, fmt.Errorf("read total supply of %s: %w", address.Hex(), err)
+	}
+	return &Token{
+		Address:     address,
+		Name:        name,
+		Symbol:      symbol,
+		Decimals:    decimals,
+		TotalSupply: totalSupply.String(),
+		UpdatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// Registry maps chain ID to token name to token. Names are matched without
+// regard to case and stored upper-case, e.g. "USDT".
+//
+// The file is JSON:
+//
+//	{"97": {"USDT": {"address": "0x...", "symbol": "USDT", "decimals": 18, ...}}}
+type Registry struct {
+	path   string
+	chains map[string]map[string]*Token
+}
+
+// Load reads the registry at path. A missing file is an empty registry
+// that Save will create.
+func Load(path string) (*Registry, error) {
+	r := &Registry{path: path, chains: make(map[string]map[string]*Token)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.chains); err != nil {
+		return nil, fmt.Errorf("token registry %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Lookup finds a token by name.
+func (r *Registry) Lookup(chainID *big.Int, name string) (*Token, bool) {
+	t, ok := r.chains[chainID.String()][strings.ToUpper(name)]
+	return t, ok
+}
+
+// ByAddress finds a registered token by address and returns its name.
+func (r *Registry) ByAddress(chainID *big.Int, address common.Address) (string, *Token, bool) {
+	for name, t := range r.chains[chainID.String()] {
+		if t.Address == address {
+			return name, t, true
+		}
+	}
+	return "", nil, false
+}
+
+// Names returns the registered names on a chain, sorted.
+func (r *Registry) Names(chainID *big.Int) []string {
+	var names []string
+	for name := range r.chains[chainID.String()] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Put adds or replaces a token.
+func (r *Registry) Put(chainID *big.Int, name string, t *Token) {
+	tokens, ok := r.chains[chainID.String()]
+	if !ok {
+		tokens = make(map[string]*Token)
+		r.chains[chainID.String()] = tokens
+	}
+	tokens[strings.ToUpper(name)] = t
+}
+
+// Remove drops a token and reports whether it was there.
+func (r *Registry) Remove(chainID *big.Int, name string) bool {
+	tokens := r.chains[chainID.String()]
+	if _, ok := tokens[strings.ToUpper(name)]; !ok {
+		return false
+	}
+	delete(tokens, strings.ToUpper(name))
+	return true
+}
+
+// Save writes the registry back, replacing the file in one step.
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r.chains, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
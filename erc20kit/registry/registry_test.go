@@ -0,0 +1,48 @@
+package registry_test
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"gb-sc-homework/erc20kit/registry"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// TestRegistry registers the token, saves the registry and finds it again
+// by name and by address after reloading.
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	chain := simulated.NewTestChain(t)
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	chainID := big.NewInt(1337)
+	meta, err := registry.Fetch(ctx, chain.Backend, chain.Token)
+	if err != nil {
+		t.Fatalf("fetch token metadata: %v", err)
+	}
+	reg, err := registry.Load(path)
+	if err != nil {
+		t.Fatalf("load empty registry: %v", err)
+	}
+	reg.Put(chainID, "ref", meta)
+	if err := reg.Save(); err != nil {
+		t.Fatalf("save registry: %v", err)
+	}
+
+	reg, err = registry.Load(path)
+	if err != nil {
+		t.Fatalf("reload registry: %v", err)
+	}
+	got, ok := reg.Lookup(chainID, "Ref")
+	if !ok || got.Address != chain.Token || got.Symbol != simulated.TokenSymbol || got.Decimals != simulated.TokenDecimals {
+		t.Fatalf("lookup = %+v, %v; want %s %s", got, ok, chain.Token.Hex(), simulated.TokenSymbol)
+	}
+	if name, _, ok := reg.ByAddress(chainID, chain.Token); !ok || name != "REF" {
+		t.Errorf("name of %s = %q, %v; want REF", chain.Token.Hex(), name, ok)
+	}
+	if _, ok := reg.Lookup(big.NewInt(1), "REF"); ok {
+		t.Error("found REF on another chain")
+	}
+}
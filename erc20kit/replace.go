@@ -0,0 +1,165 @@
+package erc20kit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ReplacementBump is the minimum fee increase, in percent, that geth and
+// most other nodes require before a pending transaction is replaced.
+const ReplacementBump = 10
+
+// TransactionReader is implemented by *ethclient.Client and the simulated
+// backend.
+type TransactionReader interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// PendingTransaction fetches a transaction that is still waiting in the
+// mempool. A mined one yields ErrNotPending.
+func PendingTransaction(ctx context.Context, backend TransactionReader, hash common.Hash) (*types.Transaction, error) {
+	tx, isPending, err := backend.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, wrap(ErrTxLookup, fmt.Errorf("tx %s: %w", hash.Hex(), err))
+	}
+	if !isPending {
+		return nil, wrap(ErrNotPending, fmt.Errorf("tx %s is already mined", hash.Hex()))
+	}
+	return tx, nil
+}
+
+// TxSender recovers the address that signed tx.
+func TxSender(tx *types.Transaction) (common.Address, error) {
+	return types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+}
+
+// Speedup resends the recipient, value and data of a pending transaction
+// at its nonce, with fees at least bump percent (and never less than
+// ReplacementBump) over the original or whatever Gas suggests now, if that
+// is higher.
+func (a *Account) Speedup(ctx context.Context, tx *types.Transaction, bump uint64) (*types.Transaction, error) {
+	return a.replace(ctx, tx, tx.To(), tx.Value(), tx.Data(), tx.AccessList(), tx.Gas(), bump)
+}
+
+// Cancel replaces a pending transaction with a 0-value transfer to the
+// account itself, priced like Speedup.
+func (a *Account) Cancel(ctx context.Context, tx *types.Transaction, bump uint64) (*types.Transaction, error) {
+	self := a.Address
+	return a.replace(ctx, tx, &self, new(big.Int), nil, nil, params.TxGas, bump)
+}
+
+func (a *Account) replace(ctx context.Context, tx *types.Transaction, to *common.Address, value *big.Int, data []byte, accessList types.AccessList, gas uint64, bump uint64) (*types.Transaction, error) {
+	sender, err := TxSender(tx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != a.Address {
+		return nil, fmt.Errorf("tx %s was sent by %s, not %s", tx.Hash().Hex(), sender.Hex(), a.Address.Hex())
+	}
+	if bump < ReplacementBump {
+		bump = ReplacementBump
+	}
+
+	replacement, err := a.SendWithNonce(ctx, tx.Nonce(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		current := GasFees{GasPrice: opts.GasPrice, GasFeeCap: opts.GasFeeCap, GasTipCap: opts.GasTipCap}
+		unsigned := replacementTx(tx, to, value, data, accessList, gas, bumpFees(tx, current, bump))
+		// the gas limit is already known, so sign without the estimate margin
+		signed, err := a.signerFn(ctx)(a.Address, unsigned)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.backend.SendTransaction(ctx, signed); err != nil {
+			return nil, err
+		}
+		return signed, nil
+	})
+	if err != nil {
+		if isNonceTooLow(err) {
+			return nil, wrap(ErrNotPending, fmt.Errorf("nonce %d of tx %s is already used: %w", tx.Nonce(), tx.Hash().Hex(), err))
+		}
+		return nil, err
+	}
+	return replacement, nil
+}
+
+// bumpFees prices a replacement for tx in the same fee model as tx, taking
+// for each fee the larger of the bumped original and the current suggestion.
+func bumpFees(tx *types.Transaction, current GasFees, percent uint64) GasFees {
+	if tx.Type() != types.DynamicFeeTxType {
+		price := current.GasPrice
+		if price == nil {
+			price = current.GasFeeCap
+		}
+		return GasFees{GasPrice: bumpFee(tx.GasPrice(), price, percent)}
+	}
+
+	tip, feeCap := current.GasTipCap, current.GasFeeCap
+	if tip == nil {
+		tip, feeCap = current.GasPrice, current.GasPrice
+	}
+	fees := GasFees{
+		GasTipCap: bumpFee(tx.GasTipCap(), tip, percent),
+		GasFeeCap: bumpFee(tx.GasFeeCap(), feeCap, percent),
+	}
+	if fees.GasFeeCap.Cmp(fees.GasTipCap) < 0 {
+		fees.GasFeeCap = new(big.Int).Set(fees.GasTipCap)
+	}
+	return fees
+}
+
+// bumpFee raises old by percent, rounding up so the node's integer check
+// passes, unless current is already higher.
+func bumpFee(old, current *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(old, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	if current != nil && current.Cmp(bumped) > 0 {
+		return new(big.Int).Set(current)
+	}
+	return bumped
+}
+
+// replacementTx builds an unsigned transaction of the same type and nonce
+// as tx with another payload and fees.
+func replacementTx(tx *types.Transaction, to *common.Address, value *big.Int, data []byte, accessList types.AccessList, gas uint64, fees GasFees) *types.Transaction {
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  fees.GasTipCap,
+			GasFeeCap:  fees.GasFeeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   fees.GasPrice,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: fees.GasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	}
+}
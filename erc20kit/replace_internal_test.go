@@ -0,0 +1,73 @@
+package erc20kit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestBumpFee checks that a bump never lands below the node's integer
+// threshold of old * (100 + percent) / 100.
+func TestBumpFee(t *testing.T) {
+	cases := []struct {
+		old, current int64
+		want         int64
+	}{
+		{100, 0, 110},   // exactly +10%
+		{10, 0, 11},     // exactly +10%
+		{101, 0, 112},   // 111.1 rounds up
+		{1, 0, 2},       // 1.1 rounds up
+		{0, 0, 0},       // nothing to bump
+		{100, 109, 110}, // a lower suggestion does not count
+		{100, 200, 200}, // a higher one wins
+	}
+	for _, c := range cases {
+		var current *big.Int
+		if c.current != 0 {
+			current = big.NewInt(c.current)
+		}
+		if got := bumpFee(big.NewInt(c.old), current, ReplacementBump); got.Int64() != c.want {
+			t.Errorf("bumpFee(%d, %d, %d) = %s, want %d", c.old, c.current, ReplacementBump, got, c.want)
+		}
+	}
+}
+
+// TestReplacementKeepsType replaces a legacy, an EIP-2930 and an EIP-1559
+// transaction and checks type, chain id and nonce of the replacement.
+func TestReplacementKeepsType(t *testing.T) {
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	accessList := types.AccessList{{Address: to}}
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 7, GasPrice: big.NewInt(100), Gas: 21000, To: &to}),
+		types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 7, GasPrice: big.NewInt(100), Gas: 21000, To: &to, AccessList: accessList}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 7, GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(100), Gas: 21000, To: &to, AccessList: accessList}),
+	}
+	for _, tx := range txs {
+		// a node suggesting legacy prices must not turn a 1559 tx into another type
+		fees := bumpFees(tx, GasFees{GasPrice: big.NewInt(1)}, ReplacementBump)
+		replacement := replacementTx(tx, tx.To(), tx.Value(), tx.Data(), tx.AccessList(), tx.Gas(), fees)
+		if replacement.Type() != tx.Type() || replacement.Nonce() != tx.Nonce() || len(replacement.AccessList()) != len(tx.AccessList()) {
+			t.Errorf("type %d replaced by type %d, nonce %d", tx.Type(), replacement.Type(), replacement.Nonce())
+		}
+		if tx.Type() != types.LegacyTxType && replacement.ChainId().Cmp(chainID) != 0 {
+			t.Errorf("type %d replacement has chain id %s", tx.Type(), replacement.ChainId())
+		}
+		if replacement.GasTipCap().Cmp(bumpFee(tx.GasTipCap(), nil, ReplacementBump)) < 0 ||
+			replacement.GasFeeCap().Cmp(bumpFee(tx.GasFeeCap(), nil, ReplacementBump)) < 0 {
+			t.Errorf("type %d replacement fees %s/%s are not bumped", tx.Type(), replacement.GasTipCap(), replacement.GasFeeCap())
+		}
+	}
+}
+
+// TestBumpFeesRaisesFeeCapToTip checks that a suggested tip above the
+// bumped fee cap lifts the cap, which nodes require to be at least the tip.
+func TestBumpFeesRaisesFeeCapToTip(t *testing.T) {
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1337), GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(20)})
+	fees := bumpFees(tx, GasFees{GasTipCap: big.NewInt(50), GasFeeCap: big.NewInt(30)}, ReplacementBump)
+	if fees.GasTipCap.Int64() != 50 || fees.GasFeeCap.Int64() != 50 {
+		t.Fatalf("fees tip %s cap %s, want 50 and 50", fees.GasTipCap, fees.GasFeeCap)
+	}
+}
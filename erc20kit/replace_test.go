@@ -0,0 +1,186 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// mempoolBackend holds sent transactions back until mine is called, so
+// they can be replaced at their nonce the way geth's pool allows it.
+type mempoolBackend struct {
+	*simulated.Backend
+	mu      sync.Mutex
+	pending map[uint64]*types.Transaction // one sender only
+}
+
+func newMempoolBackend(backend *simulated.Backend) *mempoolBackend {
+	return &mempoolBackend{Backend: backend, pending: make(map[uint64]*types.Transaction)}
+}
+
+func (b *mempoolBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	sender, err := erc20kit.TxSender(tx)
+	if err != nil {
+		return err
+	}
+	mined, err := b.Backend.NonceAt(ctx, sender, nil)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < mined {
+		return fmt.Errorf("%w: address %s, tx: %d state: %d", core.ErrNonceTooLow, sender.Hex(), tx.Nonce(), mined)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if old := b.pending[tx.Nonce()]; old != nil && !bumped(old, tx) {
+		return errors.New("replacement transaction underpriced")
+	}
+	b.pending[tx.Nonce()] = tx
+	return nil
+}
+
+// bumped applies geth's rule: both fees must rise by ReplacementBump percent.
+func bumped(old, tx *types.Transaction) bool {
+	threshold := func(fee *big.Int) *big.Int {
+		t := new(big.Int).Mul(fee, big.NewInt(100+erc20kit.ReplacementBump))
+		return t.Div(t, big.NewInt(100))
+	}
+	return tx.GasFeeCap().Cmp(old.GasFeeCap()) > 0 && tx.GasTipCap().Cmp(old.GasTipCap()) > 0 &&
+		tx.GasFeeCap().Cmp(threshold(old.GasFeeCap())) >= 0 && tx.GasTipCap().Cmp(threshold(old.GasTipCap())) >= 0
+}
+
+func (b *mempoolBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	for _, tx := range b.pending {
+		if tx.Hash() == hash {
+			b.mu.Unlock()
+			return tx, true, nil
+		}
+	}
+	b.mu.Unlock()
+	return b.Backend.TransactionByHash(ctx, hash)
+}
+
+func (b *mempoolBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	nonce, err := b.Backend.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for n := range b.pending {
+		if n >= nonce {
+			nonce = n + 1
+		}
+	}
+	return nonce, nil
+}
+
+// mine sends the held transactions to the chain in nonce order.
+func (b *mempoolBackend) mine(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	nonces := make([]uint64, 0, len(b.pending))
+	for n := range b.pending {
+		nonces = append(nonces, n)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	for _, n := range nonces {
+		if err := b.Backend.SendTransaction(ctx, b.pending[n]); err != nil {
+			return err
+		}
+		delete(b.pending, n)
+	}
+	return nil
+}
+
+// TestSpeedupAndCancel replaces pending transfers at their nonce and checks
+// that only the replacements are mined.
+func TestSpeedupAndCancel(t *testing.T) {
+	chain := newTestChain(t)
+	pool := newMempoolBackend(chain.Backend)
+	account, err := erc20kit.NewAccount(chain.ctx, pool, chain.Deployer.Signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := token.NewERC20token(chain.Token, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := func() *types.Transaction {
+		t.Helper()
+		tx, err := account.Send(chain.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return instance.Transfer(opts, chain.User.Address, wei("1"))
+		})
+		if err != nil {
+			t.Fatalf("transfer: %v", err)
+		}
+		if _, err := erc20kit.PendingTransaction(chain.ctx, pool, tx.Hash()); err != nil {
+			t.Fatalf("pending transfer: %v", err)
+		}
+		return tx
+	}
+	mined := func(step string, hashes ...common.Hash) *types.Receipt {
+		t.Helper()
+		if err := pool.mine(chain.ctx); err != nil {
+			t.Fatalf("%s: mine: %v", step, err)
+		}
+		receipt, err := erc20kit.WaitForAnyReceipt(chain.ctx, pool, hashes, erc20kit.WaitOptions{Timeout: time.Minute})
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		return receipt
+	}
+
+	original := transfer()
+	fast, err := account.Speedup(chain.ctx, original, 0)
+	if err != nil {
+		t.Fatalf("speedup: %v", err)
+	}
+	if fast.Nonce() != original.Nonce() || fast.Type() != original.Type() || *fast.To() != *original.To() || string(fast.Data()) != string(original.Data()) {
+		t.Fatalf("speedup changed the transaction: nonce %d, type %d, to %s", fast.Nonce(), fast.Type(), fast.To().Hex())
+	}
+	if receipt := mined("speedup", original.Hash(), fast.Hash()); receipt.TxHash != fast.Hash() {
+		t.Fatalf("mined %s, want the speedup %s", receipt.TxHash.Hex(), fast.Hash().Hex())
+	}
+	if _, err := erc20kit.PendingTransaction(chain.ctx, pool, fast.Hash()); !errors.Is(err, erc20kit.ErrNotPending) {
+		t.Fatalf("pending speedup after mining: want ErrNotPending, got %v", err)
+	}
+	if _, err := account.Speedup(chain.ctx, original, 0); !errors.Is(err, erc20kit.ErrNotPending) {
+		t.Fatalf("speedup of a replaced tx: want ErrNotPending, got %v", err)
+	}
+
+	stuck := transfer()
+	cancel, err := account.Cancel(chain.ctx, stuck, 20)
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancel.Nonce() != stuck.Nonce() || *cancel.To() != chain.Deployer.Address || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 {
+		t.Fatalf("cancel is not an empty self transfer at nonce %d", stuck.Nonce())
+	}
+	if cancel.GasTipCap().Cmp(new(big.Int).Div(new(big.Int).Mul(stuck.GasTipCap(), big.NewInt(120)), big.NewInt(100))) < 0 {
+		t.Fatalf("cancel tip %s is less than 20%% over %s", cancel.GasTipCap(), stuck.GasTipCap())
+	}
+	if receipt := mined("cancel", stuck.Hash(), cancel.Hash()); receipt.TxHash != cancel.Hash() {
+		t.Fatalf("mined %s, want the cancel %s", receipt.TxHash.Hex(), cancel.Hash().Hex())
+	}
+	if _, err := account.Cancel(chain.ctx, stuck, 0); !errors.Is(err, erc20kit.ErrNotPending) {
+		t.Fatalf("cancel of a mined nonce: want ErrNotPending, got %v", err)
+	}
+	chain.expectBalance("user", chain.User.Address, wei("1"))
+}
@@ -0,0 +1,163 @@
+package erc20kit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicReasons are the Solidity panic codes.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assert failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "corrupt storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+// RevertError is the decoded reason a call or transaction reverted.
+type RevertError struct {
+	// Reason is the Error(string) message, a description of a Panic code,
+	// a custom error with its arguments, or empty when the data could not
+	// be decoded.
+	Reason string
+	// Data is the raw revert data.
+	Data []byte
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return e.Reason
+	case len(e.Data) >= 4:
+		return fmt.Sprintf("unknown error %s", hexutil.Encode(e.Data[:4]))
+	default:
+		return "no reason given"
+	}
+}
+
+// DecodeRevert decodes Error(string) and Panic(uint256) revert data, and
+// custom errors declared in any of errorABIs.
+func DecodeRevert(data []byte, errorABIs ...*abi.ABI) *RevertError {
+	e := &RevertError{Data: data}
+	switch {
+	case bytes.HasPrefix(data, errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			e.Reason = reason
+		}
+	case bytes.HasPrefix(data, panicSelector) && len(data) == 4+32:
+		code := new(big.Int).SetBytes(data[4:])
+		reason, ok := panicReasons[code.Uint64()]
+		if !ok || !code.IsUint64() {
+			reason = "unknown panic"
+		}
+		e.Reason = fmt.Sprintf("panic 0x%x: %s", code, reason)
+	case len(data) >= 4:
+		e.Reason = decodeCustomError(data, errorABIs)
+	}
+	return e
+}
+
+// decodeCustomError formats data as Name(arg: value, ...) using the first
+// error in errorABIs whose selector matches.
+func decodeCustomError(data []byte, errorABIs []*abi.ABI) string {
+	for _, parsed := range errorABIs {
+		if parsed == nil {
+			continue
+		}
+		for _, abiErr := range parsed.Errors {
+			if !bytes.Equal(abiErr.ID[:4], data[:4]) {
+				continue
+			}
+			values, err := abiErr.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			return abiErr.Name + formatArgs(abiErr.Inputs, values)
+		}
+	}
+	return ""
+}
+
+// formatArgs formats decoded values as (name: value, ...).
+func formatArgs(inputs abi.Arguments, values []interface{}) string {
+	args := make([]string, len(values))
+	for i, value := range values {
+		name := inputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		args[i] = fmt.Sprintf("%s: %v", name, value)
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+// ExplainRevert replays a reverted transaction with eth_call against the
+// state its block started from and decodes the revert data. Nodes that
+// cannot serve that state, such as pruned nodes or the simulated backend,
+// get the call on the latest block instead. Transactions earlier in the
+// same block are not replayed, so in rare cases the call succeeds and the
+// returned error says so.
+func ExplainRevert(ctx context.Context, backend ethereum.ContractCaller, tx *types.Transaction, blockNumber *big.Int, errorABIs ...*abi.ABI) (*RevertError, error) {
+	from, err := TxSender(tx)
+	if err != nil {
+		return nil, err
+	}
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	parent := new(big.Int).Sub(blockNumber, big.NewInt(1))
+	_, err = backend.CallContract(ctx, msg, parent)
+	if _, ok := revertData(err); err != nil && !ok {
+		_, err = backend.CallContract(ctx, msg, nil)
+	}
+	if err == nil {
+		return nil, fmt.Errorf("tx %s does not revert when replayed", tx.Hash().Hex())
+	}
+	data, ok := revertData(err)
+	if !ok {
+		return nil, fmt.Errorf("replay tx %s: %w", tx.Hash().Hex(), err)
+	}
+	return DecodeRevert(data, errorABIs...), nil
+}
+
+// revertData extracts the revert data a node attaches to a failed
+// eth_call or eth_estimateGas.
+func revertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(s)
+	if decodeErr != nil {
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,61 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+)
+
+// TestExplainRevert mines a transfer past the sender's balance through the
+// binding and checks the reason recovered by replaying it.
+func TestExplainRevert(t *testing.T) {
+	chain := newTestChain(t)
+	tx, err := chain.Deployer.Send(chain.ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		// a fixed limit skips the estimate, which would refuse to send
+		opts.GasLimit = 100000
+		return chain.Instance.Transfer(opts, chain.User.Address, new(big.Int).Add(simulated.InitialSupply, big.NewInt(1)))
+	})
+	if err != nil {
+		t.Fatalf("send failing transfer: %v", err)
+	}
+	receipt, err := erc20kit.WaitForReceipt(chain.ctx, chain.Backend, tx.Hash(), erc20kit.WaitOptions{Timeout: time.Minute})
+	if !errors.Is(err, erc20kit.ErrReverted) {
+		t.Fatalf("failing transfer: want ErrReverted, got %v", err)
+	}
+	reason, err := erc20kit.ExplainRevert(chain.ctx, chain.Backend, tx, receipt.BlockNumber)
+	if err != nil {
+		t.Fatalf("explain revert: %v", err)
+	}
+	if reason.Reason != "ERC20: transfer amount exceeds balance" {
+		t.Errorf("explain revert: got %q", reason.Reason)
+	}
+}
+
+// TestDecodeCustomError decodes revert data of an error declared in a
+// supplied ABI.
+func TestDecodeCustomError(t *testing.T) {
+	const errorsABI = `[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`
+	parsed, err := abi.JSON(strings.NewReader(errorsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abiErr := parsed.Errors["InsufficientBalance"]
+	args, err := abiErr.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(abiErr.ID[:4:4], args...)
+	want := "InsufficientBalance(available: 1, required: 2)"
+	if got := erc20kit.DecodeRevert(data, &parsed).Reason; got != want {
+		t.Errorf("custom error: got %q, want %q", got, want)
+	}
+}
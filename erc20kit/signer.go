@@ -0,0 +1,173 @@
+package erc20kit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Signer holds the key of one address. Account signs everything through
+// it and never sees the key itself.
+type Signer interface {
+	Address() common.Address
+	// SignTx signs tx for the chain with id chainID. A signer that asks
+	// elsewhere gives up when ctx is done.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// KeySigner signs with a private key held in memory, however it was
+// obtained: decrypted from a keystore file, derived from a mnemonic or
+// given as hex.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner signs with key.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// HexSigner signs with a hex encoded private key. Keys in plain text are
+// meant for tests and throwaway accounts.
+func HexSigner(privateKeyHex string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, wrap(ErrPrivateKey, err)
+	}
+	return NewKeySigner(key), nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// KeystoreAddress reads the address of a go-ethereum keystore file without
+// decrypting it.
+func KeystoreAddress(path string) (common.Address, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var file struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return common.Address{}, fmt.Errorf("keystore %s: %w", path, err)
+	}
+	if !common.IsHexAddress(file.Address) {
+		return common.Address{}, fmt.Errorf("keystore %s: no address", path)
+	}
+	return common.HexToAddress(file.Address), nil
+}
+
+// FindKeystore returns the file in dir that holds the key of address.
+func FindKeystore(dir string, address common.Address) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if found, err := KeystoreAddress(path); err == nil && found == address {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no keystore file for %s in %s", address.Hex(), dir)
+}
+
+// KeystoreSigner decrypts a go-ethereum keystore file.
+func KeystoreSigner(path, passphrase string) (*KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, wrap(ErrPrivateKey, fmt.Errorf("keystore %s: %w", path, err))
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+// MnemonicSigner derives the key at an HD path such as m/44'/60'/0'/0/0
+// from a BIP-39 mnemonic and its optional password.
+func MnemonicSigner(mnemonic, password, path string) (*KeySigner, error) {
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, wrap(ErrPrivateKey, err)
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, wrap(ErrPrivateKey, err)
+	}
+	key, err := deriveKey(seed, derivation)
+	if err != nil {
+		return nil, wrap(ErrPrivateKey, fmt.Errorf("derive %s: %w", path, err))
+	}
+	return NewKeySigner(key), nil
+}
+
+// deriveKey walks a BIP-32 path from the master key of seed.
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+	errInvalid := errors.New("invalid child key, use the next index")
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errInvalid
+	}
+
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			// hardened: derive from the private key
+			data = append([]byte{0}, math.PaddedBigBytes(key, 32)...)
+		} else {
+			parent, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = append(data, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, errInvalid
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, errInvalid
+		}
+		chainCode = sum[32:]
+	}
+	return crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+}
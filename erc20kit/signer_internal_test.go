@@ -0,0 +1,36 @@
+package erc20kit
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestDeriveKeyVector1 walks the chain of BIP-32 test vector 1.
+func TestDeriveKeyVector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	const hardened = 0x80000000
+	cases := []struct {
+		path accounts.DerivationPath
+		key  string
+	}{
+		{accounts.DerivationPath{}, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{accounts.DerivationPath{hardened}, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{accounts.DerivationPath{hardened, 1}, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{accounts.DerivationPath{hardened, 1, hardened + 2}, "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{accounts.DerivationPath{hardened, 1, hardened + 2, 2}, "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{accounts.DerivationPath{hardened, 1, hardened + 2, 2, 1000000000}, "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, c := range cases {
+		key, err := deriveKey(seed, c.path)
+		if err != nil {
+			t.Errorf("derive %s: %v", c.path, err)
+			continue
+		}
+		if got := hex.EncodeToString(crypto.FromECDSA(key)); got != c.key {
+			t.Errorf("derive %s = %s, want %s", c.path, got, c.key)
+		}
+	}
+}
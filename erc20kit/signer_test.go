@@ -0,0 +1,51 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// TestMnemonicSigner derives the well-known accounts of the hardhat and
+// anvil test mnemonic and of the all-abandon BIP-39 one.
+func TestMnemonicSigner(t *testing.T) {
+	const mnemonic = "test test test test test test test test test test test junk"
+	for i, want := range []string{"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"} {
+		path := fmt.Sprintf("m/44'/60'/0'/0/%d", i)
+		signer, err := erc20kit.MnemonicSigner(mnemonic, "", path)
+		if err != nil || signer.Address() != common.HexToAddress(want) {
+			t.Errorf("mnemonic %s = %v, %v; want %s", path, signer, err, want)
+		}
+	}
+	const abandon = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if signer, err := erc20kit.MnemonicSigner(abandon, "", "m/44'/60'/0'/0/0"); err != nil || signer.Address() != common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94") {
+		t.Errorf("abandon mnemonic = %v, %v; want 0x9858EfFD232B4033E47d90003D41EC34EcaEda94", signer, err)
+	}
+	if _, err := erc20kit.MnemonicSigner("test test test test test test test test test test test jnuk", "", "m/44'/60'/0'/0/0"); !errors.Is(err, erc20kit.ErrPrivateKey) {
+		t.Errorf("mnemonic with a misspelt word: got %v, want %v", err, erc20kit.ErrPrivateKey)
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir := t.TempDir()
+	stored, err := keystore.StoreKey(dir, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("store key: %v", err)
+	}
+	path, err := erc20kit.FindKeystore(dir, stored.Address)
+	if err != nil {
+		t.Fatalf("find keystore: %v", err)
+	}
+	signer, err := erc20kit.KeystoreSigner(path, "secret")
+	if err != nil || signer.Address() != stored.Address {
+		t.Fatalf("unlock keystore = %v, %v; want %s", signer, err, stored.Address.Hex())
+	}
+	if _, err := erc20kit.KeystoreSigner(path, "wrong"); !errors.Is(err, erc20kit.ErrPrivateKey) {
+		t.Errorf("unlock keystore with a wrong passphrase: got %v, want %v", err, erc20kit.ErrPrivateKey)
+	}
+}
@@ -0,0 +1,132 @@
+package erc20kit
+
+import (
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Simulation is what a transaction would do if it were sent now.
+type Simulation struct {
+	// Return holds the decoded outputs of the method.
+	Return []interface{}
+	// Gas is the node's estimate, GasLimit the estimate with the account's
+	// GasLimitMargin.
+	Gas      uint64
+	GasLimit uint64
+	// Fees are the ones Gas picked; Cost is Gas at the price expected to be
+	// paid and MaxCost GasLimit at the highest price the fees allow, both
+	// in wei of the native coin.
+	Fees    GasFees
+	Cost    *big.Int
+	MaxCost *big.Int
+}
+
+// Simulate runs method on the contract at to as the account against
+// pending state, without signing or sending anything. A call that would
+// revert returns ErrWouldRevert wrapping a *RevertError with the decoded
+// reason.
+func (a *Account) Simulate(ctx context.Context, to common.Address, contract *abi.ABI, method string, args ...interface{}) (*Simulation, error) {
+	input, err := contract.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees GasFees
+	if a.Gas != nil {
+		if fees, err = a.Gas.Fees(ctx, a.backend); err != nil {
+			return nil, err
+		}
+	}
+	msg := ethereum.CallMsg{
+		From:      a.Address,
+		To:        &to,
+		GasPrice:  fees.GasPrice,
+		GasFeeCap: fees.GasFeeCap,
+		GasTipCap: fees.GasTipCap,
+		Value:     a.Auth.Value,
+		Data:      input,
+	}
+
+	var output []byte
+	if pending, ok := a.backend.(interface {
+		PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error)
+	}); ok {
+		output, err = pending.PendingCallContract(ctx, msg)
+	} else {
+		output, err = a.backend.CallContract(ctx, msg, nil)
+	}
+	if err != nil {
+		return nil, callErr(err, contract)
+	}
+	gas, err := a.backend.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, callErr(err, contract)
+	}
+	values, err := contract.Unpack(method, output)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{
+		Return:   values,
+		Gas:      gas,
+		GasLimit: gas + gas*a.GasLimitMargin/100,
+		Fees:     fees,
+	}
+	price, maxPrice, err := a.expectedPrice(ctx, fees)
+	if err != nil {
+		return nil, err
+	}
+	sim.Cost = new(big.Int).Mul(new(big.Int).SetUint64(sim.Gas), price)
+	sim.MaxCost = new(big.Int).Mul(new(big.Int).SetUint64(sim.GasLimit), maxPrice)
+	return sim, nil
+}
+
+// expectedPrice returns the price per gas likely to be paid at the current
+// base fee, and the most that could be paid.
+func (a *Account) expectedPrice(ctx context.Context, fees GasFees) (*big.Int, *big.Int, error) {
+	if fees.GasPrice != nil {
+		return fees.GasPrice, fees.GasPrice, nil
+	}
+	if fees.GasFeeCap == nil {
+		gasPrice, err := a.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, wrap(ErrGasPrice, err)
+		}
+		return gasPrice, gasPrice, nil
+	}
+	head, err := a.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, wrap(ErrHeader, err)
+	}
+	price := new(big.Int).Set(fees.GasFeeCap)
+	if head.BaseFee != nil {
+		price = effectivePrice(head, fees)
+	}
+	return price, fees.GasFeeCap, nil
+}
+
+func effectivePrice(head *types.Header, fees GasFees) *big.Int {
+	price := new(big.Int).Set(head.BaseFee)
+	if fees.GasTipCap != nil {
+		price.Add(price, fees.GasTipCap)
+	}
+	if price.Cmp(fees.GasFeeCap) > 0 {
+		price.Set(fees.GasFeeCap)
+	}
+	return price
+}
+
+// callErr turns a revert reported by the node into ErrWouldRevert with the
+// reason decoded, custom errors included, against the contract's ABI.
+func callErr(err error, contract *abi.ABI) error {
+	if data, ok := revertData(err); ok {
+		return wrap(ErrWouldRevert, DecodeRevert(data, contract))
+	}
+	return err
+}
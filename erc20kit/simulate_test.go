@@ -0,0 +1,34 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"testing"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// TestSimulate simulates a transfer that succeeds and a transferFrom past
+// the allowance, which must come back with the token's revert reason.
+func TestSimulate(t *testing.T) {
+	chain := newTestChain(t)
+	spender, owner := chain.Deployer, chain.User.Address
+	parsed, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := spender.Simulate(chain.ctx, chain.Token, parsed, "transfer", owner, wei("1"))
+	if err != nil {
+		t.Fatalf("simulate transfer: %v", err)
+	}
+	if len(sim.Return) != 1 || sim.Return[0] != true || sim.Gas == 0 || sim.Cost.Sign() <= 0 {
+		t.Errorf("simulate transfer: returns %v, gas %d, cost %s", sim.Return, sim.Gas, sim.Cost)
+	}
+
+	_, err = spender.Simulate(chain.ctx, chain.Token, parsed, "transferFrom", owner, spender.Address, wei("1000"))
+	var revert *erc20kit.RevertError
+	if !errors.Is(err, erc20kit.ErrWouldRevert) || !errors.As(err, &revert) || revert.Reason != "ERC20: insufficient allowance" {
+		t.Errorf("simulate transferFrom over the allowance: %v", err)
+	}
+}
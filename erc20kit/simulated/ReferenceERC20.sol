@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: MIT
+
+pragma solidity ^0.8.0;
+
+import "../../contracts/openzeppelin/token/ERC20/ERC20.sol";
+
+/**
+ * @dev Plain OpenZeppelin ERC20 with configurable decimals and no mint, used
+ * as the reference token on the simulated chain. The whole initial supply
+ * goes to the deployer.
+ */
+contract ReferenceERC20 is ERC20 {
+    uint8 private _decimals;
+
+    constructor(
+        string memory name_,
+        string memory symbol_,
+        uint8 decimals_,
+        uint256 initialSupply
+    ) ERC20(name_, symbol_) {
+        _decimals = decimals_;
+        _mint(_msgSender(), initialSupply);
+    }
+
+    /**
+     * @dev See {IERC20Metadata-decimals}.
+     */
+    function decimals() public view virtual override returns (uint8) {
+        return _decimals;
+    }
+}
@@ -0,0 +1,111 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// ClefStub serves the account_ methods of Clef's external API over HTTP
+// and signs every request with the signers it was given, without asking.
+type ClefStub struct {
+	// URL is the endpoint to pass to erc20kit.DialClef.
+	URL string
+
+	server   *rpc.Server
+	listener net.Listener
+}
+
+// NewClefStub starts a stub on a free local port.
+func NewClefStub(signers ...erc20kit.Signer) (*ClefStub, error) {
+	api := &clefAPI{signers: make(map[common.Address]erc20kit.Signer)}
+	for _, s := range signers {
+		api.signers[s.Address()] = s
+		api.order = append(api.order, s.Address())
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", api); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go http.Serve(listener, server)
+	return &ClefStub{URL: "http://" + listener.Addr().String(), server: server, listener: listener}, nil
+}
+
+// Close stops serving.
+func (s *ClefStub) Close() {
+	s.listener.Close()
+	s.server.Stop()
+}
+
+type clefAPI struct {
+	signers map[common.Address]erc20kit.Signer
+	order   []common.Address
+}
+
+// Version is account_version.
+func (api *clefAPI) Version() string {
+	return "6.1.0"
+}
+
+// List is account_list.
+func (api *clefAPI) List() []common.Address {
+	return api.order
+}
+
+// SignTransaction is account_signTransaction.
+func (api *clefAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, methodSelector *string) (*erc20kit.ClefSignResult, error) {
+	signer, ok := api.signers[args.From.Address()]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %s", args.From.Address().Hex())
+	}
+	if args.ChainID == nil {
+		return nil, errors.New("chainId is required")
+	}
+	if args.GasPrice == nil && args.MaxFeePerGas == nil {
+		return nil, errors.New("gasPrice or maxFeePerGas is required")
+	}
+	signed, err := signer.SignTx(ctx, args.ToTransaction(), args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &erc20kit.ClefSignResult{Raw: raw, Tx: signed}, nil
+}
+
+// SignTypedData is account_signTypedData.
+func (api *clefAPI) SignTypedData(ctx context.Context, address common.Address, data apitypes.TypedData) (hexutil.Bytes, error) {
+	signer, ok := api.signers[address].(erc20kit.TypedDataSigner)
+	if !ok {
+		return nil, fmt.Errorf("cannot sign typed data as %s", address.Hex())
+	}
+	return signer.SignTypedData(ctx, data)
+}
+
+// SignData is account_signData. Only text/plain, the EIP-191 personal
+// message, is supported.
+func (api *clefAPI) SignData(ctx context.Context, contentType string, address common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if contentType != "text/plain" {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	signer, ok := api.signers[address].(erc20kit.MessageSigner)
+	if !ok {
+		return nil, fmt.Errorf("cannot sign messages as %s", address.Hex())
+	}
+	return signer.SignMessage(ctx, data)
+}
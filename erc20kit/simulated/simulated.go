@@ -0,0 +1,150 @@
+// Package simulated runs the kit against go-ethereum's in-memory simulated
+// backend, so the token flows can be exercised offline.
+package simulated
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// Reference token parameters used by NewChain.
+const (
+	TokenName     = "Reference Token"
+	TokenSymbol   = "REF"
+	TokenDecimals = 18
+)
+
+const blockGasLimit = uint64(30000000)
+
+// InitialSupply is minted to the deployer: one million whole tokens.
+var InitialSupply = erc20kit.ToWei(int64(1000000), TokenDecimals)
+
+// Backend is a simulated backend that mines every transaction as soon as it
+// is sent, so receipts are available right after SendTransaction returns.
+type Backend struct {
+	*backends.SimulatedBackend
+}
+
+var _ erc20kit.Backend = (*Backend)(nil)
+
+// SendTransaction refuses a transaction its sender cannot pay for, as a
+// node's transaction pool does, where the simulated backend would panic.
+func (b *Backend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	balance, err := b.BalanceAt(ctx, sender, nil)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(tx.Cost()) < 0 {
+		return fmt.Errorf("%w: address %s have %s want %s", core.ErrInsufficientFunds, sender.Hex(), balance, tx.Cost())
+	}
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+	return nil
+}
+
+// NetworkID returns the chain ID of the simulated chain config.
+func (b *Backend) NetworkID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.Blockchain().Config().ChainID), nil
+}
+
+// ChainID returns the chain ID of the simulated chain config, which the
+// simulated backend does not serve itself.
+func (b *Backend) ChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.Blockchain().Config().ChainID), nil
+}
+
+// Chain is a funded deployer and user with the reference token deployed by
+// the deployer, mirroring the accounts main.go works with.
+type Chain struct {
+	Backend  *Backend
+	Deployer *erc20kit.Account
+	User     *erc20kit.Account
+	Token    common.Address
+	// Instance is the reference token bound to Backend.
+	Instance *token.ERC20token
+}
+
+// NewChain starts a simulated chain, funds two fresh accounts with native
+// coin and deploys the reference token.
+func NewChain(ctx context.Context) (*Chain, error) {
+	deployerKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	userKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	funds := erc20kit.ToWei(int64(100), 18)
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(deployerKey.PublicKey): {Balance: funds},
+		crypto.PubkeyToAddress(userKey.PublicKey):     {Balance: funds},
+	}
+	backend := &Backend{backends.NewSimulatedBackend(alloc, blockGasLimit)}
+
+	deployer, err := erc20kit.NewAccount(ctx, backend, erc20kit.NewKeySigner(deployerKey))
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+	user, err := erc20kit.NewAccount(ctx, backend, erc20kit.NewKeySigner(userKey))
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+
+	var address common.Address
+	tx, err := deployer.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		var (
+			tx  *types.Transaction
+			err error
+		)
+		address, tx, err = DeployReferenceToken(opts, backend, TokenName, TokenSymbol, TokenDecimals, InitialSupply)
+		return tx, err
+	})
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("deploy reference token: %w", err)
+	}
+	if _, err := bind.WaitDeployed(ctx, backend, tx); err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("deploy reference token: %w", err)
+	}
+
+	instance, err := token.NewERC20token(address, backend)
+	if err != nil {
+		backend.Close()
+		return nil, err
+	}
+
+	return &Chain{
+		Backend:  backend,
+		Deployer: deployer,
+		User:     user,
+		Token:    address,
+		Instance: instance,
+	}, nil
+}
+
+// Close stops the simulated backend.
+func (c *Chain) Close() error {
+	return c.Backend.Close()
+}
@@ -0,0 +1,18 @@
+package simulated
+
+import (
+	"context"
+	"testing"
+)
+
+// NewTestChain is NewChain for a test: it fails tb when the chain cannot
+// be set up and closes the chain when tb is done.
+func NewTestChain(tb testing.TB) *Chain {
+	tb.Helper()
+	chain, err := NewChain(context.Background())
+	if err != nil {
+		tb.Fatalf("simulated chain: %v", err)
+	}
+	tb.Cleanup(func() { chain.Close() })
+	return chain
+}
@@ -0,0 +1,126 @@
+package erc20kit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// TypedDataSigner is a Signer that can also sign EIP-712 typed data.
+// KeySigner and ClefSigner are both.
+type TypedDataSigner interface {
+	Signer
+	// SignTypedData returns a 65 byte [R || S || V] signature of the
+	// TypedDataHash of data, with V 27 or 28 as contracts expect it.
+	SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error)
+}
+
+// TypedDataHash returns the EIP-712 digest of data, the hash that is
+// actually signed: keccak256("\x19\x01" || domainSeparator || hashStruct(message)).
+func TypedDataHash(data apitypes.TypedData) (common.Hash, error) {
+	domain, err := data.HashStruct("EIP712Domain", data.Domain.Map())
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("typed data domain: %w", err)
+	}
+	message, err := data.HashStruct(data.PrimaryType, data.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("typed data %s: %w", data.PrimaryType, err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain, message), nil
+}
+
+// ParseTypedData decodes an EIP-712 payload as wallets send it to
+// eth_signTypedData_v4. A numeric domain chainId, which most of them use,
+// is accepted as well as a string.
+func ParseTypedData(payload []byte) (apitypes.TypedData, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return apitypes.TypedData{}, err
+	}
+	if domain, ok := fields["domain"]; ok {
+		var domainFields map[string]json.RawMessage
+		if err := json.Unmarshal(domain, &domainFields); err != nil {
+			return apitypes.TypedData{}, fmt.Errorf("domain: %w", err)
+		}
+		if id := domainFields["chainId"]; len(id) > 0 && id[0] != '"' && string(id) != "null" {
+			domainFields["chainId"] = json.RawMessage(strconv.Quote(string(id)))
+			fields["domain"], _ = json.Marshal(domainFields)
+		}
+	}
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return apitypes.TypedData{}, err
+	}
+	var data apitypes.TypedData
+	if err := json.Unmarshal(normalized, &data); err != nil {
+		return apitypes.TypedData{}, err
+	}
+	if _, ok := data.Types["EIP712Domain"]; !ok {
+		return apitypes.TypedData{}, errors.New("typed data has no EIP712Domain type")
+	}
+	if _, ok := data.Types[data.PrimaryType]; !ok {
+		return apitypes.TypedData{}, fmt.Errorf("typed data has no type for primaryType %q", data.PrimaryType)
+	}
+	return data, nil
+}
+
+// RecoverTypedData returns the address that signed data.
+func RecoverTypedData(data apitypes.TypedData, signature []byte) (common.Address, error) {
+	hash, err := TypedDataHash(data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return recoverHash(hash, signature)
+}
+
+// SignTypedData signs data with the account's signer, if it can.
+func (a *Account) SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error) {
+	signer, ok := a.Signer.(TypedDataSigner)
+	if !ok {
+		return nil, fmt.Errorf("signer of %s cannot sign typed data", a.Address.Hex())
+	}
+	return signer.SignTypedData(ctx, data)
+}
+
+func (s *KeySigner) SignTypedData(ctx context.Context, data apitypes.TypedData) ([]byte, error) {
+	hash, err := TypedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.signHash(hash)
+}
+
+// signHash signs a 32 byte digest and moves V to 27/28.
+func (s *KeySigner) signHash(hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash[:], s.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// recoverHash is the inverse of signHash. It accepts V as 0/1 or 27/28.
+func recoverHash(hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature is %d bytes, want %d", len(signature), crypto.SignatureLength)
+	}
+	sig := common.CopyBytes(signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	if sig[crypto.RecoveryIDOffset] > 1 {
+		return common.Address{}, errors.New("signature has an invalid recovery id")
+	}
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,226 @@
+package erc20kit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ToDecimal wei to decimals. Unsupported input types yield zero; use
+// ToDecimalExact to get an error instead.
+func ToDecimal(ivalue interface{}, decimals int) decimal.Decimal {
+	value := new(big.Int)
+	switch v := ivalue.(type) {
+	case string:
+		value.SetString(v, 10)
+	case *big.Int:
+		value = v
+	}
+
+	mul := decimal.NewFromFloat(float64(10)).Pow(decimal.NewFromFloat(float64(decimals)))
+	num, _ := decimal.NewFromString(value.String())
+	result := num.Div(mul)
+
+	return result
+}
+
+// ToWei decimals to wei. Fractional wei is truncated and unparsable input
+// yields zero; use ToWeiExact to get an error instead.
+func ToWei(iamount interface{}, decimals int) *big.Int {
+	amount := decimal.NewFromFloat(0)
+	switch v := iamount.(type) {
+	case string:
+		amount, _ = decimal.NewFromString(v)
+	case float64:
+		amount = decimal.NewFromFloat(v)
+	case int64:
+		amount = decimal.NewFromFloat(float64(v))
+	case decimal.Decimal:
+		amount = v
+	case *decimal.Decimal:
+		amount = *v
+	}
+
+	mul := decimal.NewFromFloat(float64(10)).Pow(decimal.NewFromFloat(float64(decimals)))
+	result := amount.Mul(mul)
+
+	wei := new(big.Int)
+	wei.SetString(result.String(), 10)
+
+	return wei
+}
+
+// maxExponent is the exponent of the largest power of ten a uint256 holds.
+const maxExponent = 77
+
+// ToWeiExact converts a human amount to its integer base unit value. Unlike
+// ToWei it never rounds: amounts with more fractional digits than decimals,
+// negative amounts and unparsable or unsupported inputs are errors.
+//
+// Accepted inputs are strings (plain or scientific notation such as "1.5e3"),
+// all integer kinds, float32/float64, *big.Int, *big.Float, decimal.Decimal
+// and *decimal.Decimal.
+func ToWeiExact(iamount interface{}, decimals int) (*big.Int, error) {
+	if decimals < 0 {
+		return nil, wrap(ErrInvalidAmount, fmt.Errorf("negative decimals %d", decimals))
+	}
+	amount, err := parseAmount(iamount)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() < 0 {
+		return nil, wrap(ErrNegativeAmount, fmt.Errorf("%s", amount))
+	}
+	// checked before scaling, which would build 10^exp for "1e2000000000";
+	// trailing zeros of the coefficient do not count, "1.000" is exact
+	coefficient, exp := stripZeros(amount)
+	if exp > maxExponent+int64(decimals) || -exp > maxExponent+int64(decimals) {
+		return nil, wrap(ErrInvalidAmount, fmt.Errorf("exponent %d out of range", exp))
+	}
+
+	wei := decimal.NewFromBigInt(coefficient, int32(exp)).Shift(int32(decimals))
+	if !wei.Equal(wei.Truncate(0)) {
+		return nil, wrap(ErrTooManyDecimals, fmt.Errorf("%s has more than %d fractional digits", amount, decimals))
+	}
+	// the ABI encoder silently wraps anything wider modulo 2^256
+	value := wei.BigInt()
+	if value.Sign() < 0 || value.BitLen() > 256 {
+		return nil, wrap(ErrInvalidAmount, fmt.Errorf("%s does not fit in uint256", amount))
+	}
+	return value, nil
+}
+
+// stripZeros returns the coefficient of amount without trailing zeros and the
+// matching exponent.
+func stripZeros(amount decimal.Decimal) (*big.Int, int64) {
+	coefficient, exp := amount.Coefficient(), int64(amount.Exponent())
+	if coefficient.Sign() == 0 {
+		return coefficient, 0
+	}
+	ten := big.NewInt(10)
+	quo, rem := new(big.Int), new(big.Int)
+	for {
+		quo.QuoRem(coefficient, ten, rem)
+		if rem.Sign() != 0 {
+			return coefficient, exp
+		}
+		coefficient, quo = quo, coefficient
+		exp++
+	}
+}
+
+// ToDecimalExact converts an integer base unit value to a human amount
+// without loss. Strings must be base 10 integers.
+func ToDecimalExact(ivalue interface{}, decimals int) (decimal.Decimal, error) {
+	if decimals < 0 {
+		return decimal.Decimal{}, wrap(ErrInvalidAmount, fmt.Errorf("negative decimals %d", decimals))
+	}
+
+	var value *big.Int
+	switch v := ivalue.(type) {
+	case string:
+		var ok bool
+		value, ok = new(big.Int).SetString(strings.TrimSpace(v), 10)
+		if !ok {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, fmt.Errorf("%q is not a base 10 integer", v))
+		}
+	case *big.Int:
+		if v == nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, errors.New("nil *big.Int"))
+		}
+		value = v
+	default:
+		i, ok := integerValue(ivalue)
+		if !ok {
+			return decimal.Decimal{}, wrap(ErrUnsupportedType, fmt.Errorf("%T", ivalue))
+		}
+		value = i
+	}
+
+	if value.Sign() < 0 {
+		return decimal.Decimal{}, wrap(ErrNegativeAmount, fmt.Errorf("%s", value))
+	}
+	return decimal.NewFromBigInt(value, -int32(decimals)), nil
+}
+
+func parseAmount(iamount interface{}) (decimal.Decimal, error) {
+	switch v := iamount.(type) {
+	case string:
+		amount, err := decimal.NewFromString(strings.TrimSpace(v))
+		if err != nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, err)
+		}
+		return amount, nil
+	case float64:
+		return floatAmount(v)
+	case float32:
+		return floatAmount(float64(v))
+	case *big.Int:
+		if v == nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, errors.New("nil *big.Int"))
+		}
+		return decimal.NewFromBigInt(v, 0), nil
+	case *big.Float:
+		if v == nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, errors.New("nil *big.Float"))
+		}
+		if v.IsInf() {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, errors.New("infinite amount"))
+		}
+		amount, err := decimal.NewFromString(v.Text('g', -1))
+		if err != nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, err)
+		}
+		return amount, nil
+	case decimal.Decimal:
+		return v, nil
+	case *decimal.Decimal:
+		if v == nil {
+			return decimal.Decimal{}, wrap(ErrInvalidAmount, errors.New("nil *decimal.Decimal"))
+		}
+		return *v, nil
+	}
+
+	i, ok := integerValue(iamount)
+	if !ok {
+		return decimal.Decimal{}, wrap(ErrUnsupportedType, fmt.Errorf("%T", iamount))
+	}
+	return decimal.NewFromBigInt(i, 0), nil
+}
+
+func floatAmount(v float64) (decimal.Decimal, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return decimal.Decimal{}, wrap(ErrInvalidAmount, fmt.Errorf("%v", v))
+	}
+	return decimal.NewFromFloat(v), nil
+}
+
+func integerValue(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case int:
+		return big.NewInt(int64(v)), true
+	case int8:
+		return big.NewInt(int64(v)), true
+	case int16:
+		return big.NewInt(int64(v)), true
+	case int32:
+		return big.NewInt(int64(v)), true
+	case int64:
+		return big.NewInt(v), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint8:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Int).SetUint64(v), true
+	}
+	return nil, false
+}
@@ -0,0 +1,132 @@
+package erc20kit_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/shopspring/decimal"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// maxDecimals bounds the decimals of the random checks.
+const maxDecimals = 36
+
+func quickConfig() *quick.Config {
+	return &quick.Config{MaxCount: 2000, Rand: rand.New(rand.NewSource(1))}
+}
+
+// TestWeiRoundTrip checks that ToWeiExact undoes ToDecimalExact for any
+// uint256 value.
+func TestWeiRoundTrip(t *testing.T) {
+	check := func(words [4]uint64, d uint8) bool {
+		decimals := int(d) % (maxDecimals + 1)
+		wei := new(big.Int)
+		for _, w := range words {
+			wei.Lsh(wei, 64).Or(wei, new(big.Int).SetUint64(w))
+		}
+		amount, err := erc20kit.ToDecimalExact(wei, decimals)
+		if err != nil {
+			t.Logf("ToDecimalExact(%s, %d): %v", wei, decimals, err)
+			return false
+		}
+		back, err := erc20kit.ToWeiExact(amount.String(), decimals)
+		if err != nil || back.Cmp(wei) != 0 {
+			t.Logf("ToWeiExact(%s, %d) = %v, %v; want %s", amount, decimals, back, err, wei)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(check, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestAmountRoundTrip checks that ToDecimalExact undoes ToWeiExact for any
+// amount with at most decimals fractional digits.
+func TestAmountRoundTrip(t *testing.T) {
+	check := func(whole uint64, fraction []byte, d uint8) bool {
+		decimals := int(d) % (maxDecimals + 1)
+		s := amountString(whole, fraction, decimals)
+		wei, err := erc20kit.ToWeiExact(s, decimals)
+		if err != nil {
+			t.Logf("ToWeiExact(%q, %d): %v", s, decimals, err)
+			return false
+		}
+		amount, err := erc20kit.ToDecimalExact(wei, decimals)
+		if err != nil || !amount.Equal(decimal.RequireFromString(s)) {
+			t.Logf("ToDecimalExact(ToWeiExact(%q, %d)) = %s, %v", s, decimals, amount, err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(check, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+// amountString formats whole with the first decimals bytes of fraction as
+// fractional digits.
+func amountString(whole uint64, fraction []byte, decimals int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprint(whole))
+	if len(fraction) > decimals {
+		fraction = fraction[:decimals]
+	}
+	if len(fraction) > 0 {
+		b.WriteByte('.')
+		for _, digit := range fraction {
+			b.WriteByte('0' + digit%10)
+		}
+	}
+	return b.String()
+}
+
+func TestToWeiExactRejects(t *testing.T) {
+	cases := []struct {
+		amount   interface{}
+		decimals int
+		kind     error
+	}{
+		{"1.0000001", 6, erc20kit.ErrTooManyDecimals},
+		{"1e-19", 18, erc20kit.ErrTooManyDecimals},
+		{"-1", 18, erc20kit.ErrNegativeAmount},
+		{int8(-1), 18, erc20kit.ErrNegativeAmount},
+		{"1,5", 18, erc20kit.ErrInvalidAmount},
+		{"1e2000000000", 18, erc20kit.ErrInvalidAmount},
+		{"1e-2000000000", 18, erc20kit.ErrInvalidAmount},
+		{"2e59", 18, erc20kit.ErrInvalidAmount},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639936", 0, erc20kit.ErrInvalidAmount},
+		{[]byte("1"), 18, erc20kit.ErrUnsupportedType},
+	}
+	for _, c := range cases {
+		if _, err := erc20kit.ToWeiExact(c.amount, c.decimals); !errors.Is(err, c.kind) {
+			t.Errorf("ToWeiExact(%#v, %d) = %v, want %v", c.amount, c.decimals, err, c.kind)
+		}
+	}
+}
+
+func TestToWeiExactInputs(t *testing.T) {
+	cases := []struct {
+		amount interface{}
+		want   string
+	}{
+		{"1.5e3", "1500000000000000000000"},
+		{"1e59", "1" + strings.Repeat("0", 77)},
+		{"1." + strings.Repeat("0", 100), "1000000000000000000"},
+		{"115792089237316195423570985008687907853269984665640564039457.584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{uint64(7), "7000000000000000000"},
+		{big.NewFloat(0.25), "250000000000000000"},
+	}
+	for _, c := range cases {
+		wei, err := erc20kit.ToWeiExact(c.amount, 18)
+		if err != nil || wei.String() != c.want {
+			t.Errorf("ToWeiExact(%v, 18) = %v, %v; want %s", c.amount, wei, err, c.want)
+		}
+	}
+}
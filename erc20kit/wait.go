@@ -0,0 +1,207 @@
+package erc20kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultPollInterval is used when WaitOptions.PollInterval is not set.
+const DefaultPollInterval = 3 * time.Second
+
+// ReceiptBackend is what the confirmation waiter needs from a node.
+type ReceiptBackend interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// HeadSubscriber is implemented by websocket and IPC clients. The waiter
+// uses it to wake up on new blocks instead of polling.
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// WaitOptions control WaitForReceipt.
+type WaitOptions struct {
+	// Confirmations is the number of blocks, counting the one the
+	// transaction is mined in, that must exist before it is accepted.
+	// Zero means one.
+	Confirmations uint64
+	// PollInterval is how often the node is asked for the receipt when no
+	// new head arrives.
+	PollInterval time.Duration
+	// Timeout bounds the whole wait. Zero means no limit besides ctx.
+	Timeout time.Duration
+	// OnError, when set, is told about failed requests to the node, which
+	// the wait retries until it times out.
+	OnError func(err error)
+}
+
+// WaitForReceipt waits until the transaction is mined and buried under the
+// requested number of confirmations, and returns its receipt.
+//
+// New heads are used when the backend supports subscriptions; otherwise, or
+// when the subscription fails, the receipt is polled. A receipt that
+// disappears or whose block leaves the canonical chain is waited for
+// again, as the transaction is usually mined anew; only when the wait
+// times out without it does that yield ErrReorged. Failed requests to the
+// node are retried on the next head or tick as well.
+// A mined but failed transaction returns its receipt together with
+// ErrReverted.
+func WaitForReceipt(ctx context.Context, backend ReceiptBackend, txHash common.Hash, opts WaitOptions) (*types.Receipt, error) {
+	return WaitForAnyReceipt(ctx, backend, []common.Hash{txHash}, opts)
+}
+
+// WaitForAnyReceipt is WaitForReceipt for transactions of which at most one
+// can be mined, such as a transaction and its replacements at the same
+// nonce. The receipt's TxHash tells which one made it.
+func WaitForAnyReceipt(ctx context.Context, backend ReceiptBackend, txHashes []common.Hash, opts WaitOptions) (*types.Receipt, error) {
+	if len(txHashes) == 0 {
+		return nil, errors.New("no transactions to wait for")
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	var (
+		heads  chan *types.Header
+		subErr <-chan error
+	)
+	if hs, ok := backend.(HeadSubscriber); ok {
+		ch := make(chan *types.Header, 16)
+		sub, err := hs.SubscribeNewHead(ctx, ch)
+		if err == nil {
+			defer sub.Unsubscribe()
+			heads, subErr = ch, sub.Err()
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	confirmations := opts.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	waiters := make([]*waiter, len(txHashes))
+	for i, txHash := range txHashes {
+		waiters[i] = &waiter{backend: backend, txHash: txHash, confirmations: confirmations}
+	}
+	for {
+		for _, w := range waiters {
+			receipt, err := w.check(ctx)
+			w.failed = err
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, waitErr(ctx, waiters)
+				}
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+				continue
+			}
+			if receipt != nil {
+				if receipt.Status != types.ReceiptStatusSuccessful {
+					return receipt, wrap(ErrReverted, fmt.Errorf("tx %s in block %s", w.txHash.Hex(), receipt.BlockNumber))
+				}
+				return receipt, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, waitErr(ctx, waiters)
+		case <-heads:
+		case <-subErr:
+			// the subscription is gone, keep going on the ticker alone
+			heads, subErr = nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitErr explains why the wait ended with ctx: a transaction that was
+// mined and did not come back after a reorg rather than a plain timeout,
+// or a node that kept failing.
+func waitErr(ctx context.Context, waiters []*waiter) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+	for _, w := range waiters {
+		if w.reorged != nil {
+			return wrap(ErrReorged, w.reorged)
+		}
+	}
+	for _, w := range waiters {
+		if w.failed != nil {
+			return wrap(ErrTimeout, fmt.Errorf("%v, last error: %w", ctx.Err(), w.failed))
+		}
+	}
+	return wrap(ErrTimeout, ctx.Err())
+}
+
+type waiter struct {
+	backend       ReceiptBackend
+	txHash        common.Hash
+	confirmations uint64
+	seen          *types.Receipt
+	// reorged says how the seen receipt was lost, until it is back
+	reorged error
+	// failed is the error of the last check, if it failed
+	failed error
+}
+
+// check returns the receipt once it is deep enough, or nil while the
+// transaction is pending or not yet confirmed.
+func (w *waiter) check(ctx context.Context) (*types.Receipt, error) {
+	receipt, err := w.backend.TransactionReceipt(ctx, w.txHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, wrap(ErrReceipt, err)
+	}
+	// some backends report a missing receipt as nil without an error
+	if receipt == nil {
+		if w.seen != nil {
+			w.reorged = fmt.Errorf("tx %s was mined in block %s and is gone", w.txHash.Hex(), w.seen.BlockHash.Hex())
+		}
+		return nil, nil
+	}
+	// mined again in another block after a reorg: count from the new one
+	w.seen = receipt
+	w.reorged = nil
+
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, wrap(ErrHeader, err)
+	}
+	if head.Number.Cmp(receipt.BlockNumber) < 0 {
+		// the node serving the head lags behind the one serving receipts
+		return nil, nil
+	}
+	depth := new(big.Int).Sub(head.Number, receipt.BlockNumber)
+	if depth.Uint64()+1 < w.confirmations {
+		return nil, nil
+	}
+
+	included, err := w.backend.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, wrap(ErrHeader, err)
+	}
+	if included.Hash() != receipt.BlockHash {
+		// the node may not have reindexed the receipt yet
+		w.reorged = fmt.Errorf("block %s of tx %s is no longer canonical", receipt.BlockHash.Hex(), w.txHash.Hex())
+		return nil, nil
+	}
+	return receipt, nil
+}
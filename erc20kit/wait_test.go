@@ -0,0 +1,106 @@
+package erc20kit_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// reorgBackend serves a receipt from its list per poll, the last one for
+// ever after, on a chain whose head is block 10.
+type reorgBackend struct {
+	receipts []*types.Receipt
+	polls    int
+}
+
+func (b *reorgBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	i := b.polls
+	if i >= len(b.receipts) {
+		i = len(b.receipts) - 1
+	}
+	b.polls++
+	return b.receipts[i], nil
+}
+
+func (b *reorgBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		number = big.NewInt(10)
+	}
+	return &types.Header{Number: number}, nil
+}
+
+// receiptIn returns a successful receipt in the canonical block number of
+// reorgBackend.
+func receiptIn(number int64) *types.Receipt {
+	header := &types.Header{Number: big.NewInt(number)}
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: header.Number, BlockHash: header.Hash()}
+}
+
+func TestWaitForReorgedReceipt(t *testing.T) {
+	ctx := context.Background()
+	opts := erc20kit.WaitOptions{Confirmations: 3, PollInterval: time.Millisecond, Timeout: 200 * time.Millisecond}
+
+	// seen in block 9, gone, then mined again in block 8
+	backend := &reorgBackend{receipts: []*types.Receipt{receiptIn(9), nil, nil, receiptIn(8)}}
+	receipt, err := erc20kit.WaitForReceipt(ctx, backend, common.Hash{1}, opts)
+	if err != nil || receipt.BlockNumber.Int64() != 8 {
+		t.Fatalf("receipt back in block 8 = %v, %v", receipt, err)
+	}
+
+	backend = &reorgBackend{receipts: []*types.Receipt{receiptIn(9), nil}}
+	if _, err := erc20kit.WaitForReceipt(ctx, backend, common.Hash{1}, opts); !errors.Is(err, erc20kit.ErrReorged) {
+		t.Fatalf("receipt gone for good: %v, want %v", err, erc20kit.ErrReorged)
+	}
+
+	backend = &reorgBackend{receipts: []*types.Receipt{nil}}
+	if _, err := erc20kit.WaitForReceipt(ctx, backend, common.Hash{1}, opts); !errors.Is(err, erc20kit.ErrTimeout) {
+		t.Fatalf("never mined: %v, want %v", err, erc20kit.ErrTimeout)
+	}
+}
+
+// flakyBackend fails its first requests the way an overloaded node does.
+type flakyBackend struct {
+	reorgBackend
+	failures int
+}
+
+func (b *flakyBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if b.failures > 0 {
+		b.failures--
+		return nil, errors.New("502 Bad Gateway")
+	}
+	return b.reorgBackend.TransactionReceipt(ctx, txHash)
+}
+
+// TestWaitRetriesNodeErrors checks that failed requests are reported and
+// retried instead of ending the wait, and that a node failing until the
+// timeout is named in the error.
+func TestWaitRetriesNodeErrors(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	opts := erc20kit.WaitOptions{PollInterval: time.Millisecond, Timeout: 200 * time.Millisecond, OnError: func(err error) {
+		reported = append(reported, err)
+	}}
+
+	backend := &flakyBackend{reorgBackend: reorgBackend{receipts: []*types.Receipt{receiptIn(9)}}, failures: 3}
+	receipt, err := erc20kit.WaitForReceipt(ctx, backend, common.Hash{1}, opts)
+	if err != nil || receipt.BlockNumber.Int64() != 9 {
+		t.Fatalf("receipt after node errors = %v, %v", receipt, err)
+	}
+	if len(reported) != 3 || !errors.Is(reported[0], erc20kit.ErrReceipt) {
+		t.Fatalf("reported %v, want 3 receipt errors", reported)
+	}
+
+	backend = &flakyBackend{reorgBackend: reorgBackend{receipts: []*types.Receipt{receiptIn(9)}}, failures: 1 << 30}
+	_, err = erc20kit.WaitForReceipt(ctx, backend, common.Hash{1}, opts)
+	if !errors.Is(err, erc20kit.ErrTimeout) || !errors.Is(err, erc20kit.ErrReceipt) {
+		t.Fatalf("node failing until the timeout: %v, want %v with the last %v", err, erc20kit.ErrTimeout, erc20kit.ErrReceipt)
+	}
+}
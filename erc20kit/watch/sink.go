@@ -0,0 +1,106 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sink delivers notifications somewhere.
+type Sink interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// JSONLines writes every notification as one line of JSON.
+type JSONLines struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{w: w}
+}
+
+func (j *JSONLines) Send(ctx context.Context, n Notification) error {
+	line, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.w.Write(append(line, '\n'))
+	return err
+}
+
+// Webhook POSTs every notification as JSON to URL. Failed deliveries are
+// retried with doubling delays, except for client errors other than 429
+// which would fail the same way again. Once the retries run out the error
+// goes back to the watcher, which stops and resumes from that event.
+type Webhook struct {
+	URL     string
+	Client  *http.Client
+	Retries int
+	// Backoff is the delay before the first retry.
+	Backoff time.Duration
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:     url,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Retries: 5,
+		Backoff: time.Second,
+	}
+}
+
+func (h *Webhook) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	delay := h.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := h.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= h.Retries {
+			return fmt.Errorf("webhook %s: %w", h.URL, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post delivers body once and reports whether a failure is worth retrying.
+func (h *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+}
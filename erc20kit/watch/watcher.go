@@ -0,0 +1,337 @@
+// Package watch streams a token's Transfer and Approval events to a Sink,
+// resubscribing after the connection drops and filling the gap from logs.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// Event kinds of a Notification.
+const (
+	EventTransfer = "transfer"
+	EventApproval = "approval"
+)
+
+// Notification is one event as delivered to a Sink. Transfers fill From
+// and To, approvals Owner and Spender. Removed is set when a reorg takes
+// back an event that was already delivered.
+type Notification struct {
+	Event    string `json:"event"`
+	Token    string `json:"token"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	Spender  string `json:"spender,omitempty"`
+	Amount   string `json:"amount"`
+	Value    string `json:"value"`
+	Block    uint64 `json:"block"`
+	TxHash   string `json:"tx"`
+	LogIndex uint   `json:"logIndex"`
+	Removed  bool   `json:"removed,omitempty"`
+}
+
+// Backend is what a watcher needs from a node: logs and new heads, to know
+// how far it has followed the chain.
+type Backend interface {
+	bind.ContractFilterer
+	erc20kit.HeadSubscriber
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// MaxBackoff caps the delay between resubscription attempts.
+const MaxBackoff = time.Minute
+
+// Watcher streams the events of one token that match its filters. An
+// empty filter list matches any address.
+type Watcher struct {
+	Token    common.Address
+	Decimals int
+	Sink     Sink
+
+	Transfers bool
+	From      []common.Address
+	To        []common.Address
+
+	Approvals bool
+	Owner     []common.Address
+	Spender   []common.Address
+
+	// OnError, when set, is told about dropped subscriptions and failed
+	// deliveries; the watcher itself carries on, from the failed event on.
+	OnError func(err error)
+
+	backend  Backend
+	filterer *token.ERC20tokenFilterer
+	// last is the newest head or event block seen, where the gap backfill
+	// after a resubscription starts
+	last *uint64
+	// delivered remembers recent events so the backfill and the new
+	// subscription do not repeat them
+	delivered map[eventKey]bool
+}
+
+type eventKey struct {
+	block    uint64
+	tx       string
+	logIndex uint
+	removed  bool
+}
+
+// New returns a watcher for both event kinds with no filters.
+func New(tokenAddress common.Address, decimals int, backend Backend, sink Sink) (*Watcher, error) {
+	f, err := token.NewERC20tokenFilterer(tokenAddress, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		Token:     tokenAddress,
+		Decimals:  decimals,
+		Sink:      sink,
+		Transfers: true,
+		Approvals: true,
+		backend:   backend,
+		filterer:  f,
+		delivered: make(map[eventKey]bool),
+	}, nil
+}
+
+// Run watches until ctx is done. A backend without subscriptions, such as
+// an HTTP endpoint, is an error straight away; any other failure is
+// retried with growing delays. An event the sink fails to take ends the
+// session, and the next one resumes from its block.
+func (w *Watcher) Run(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := w.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			return fmt.Errorf("watch needs a websocket or IPC endpoint: %w", err)
+		}
+		w.report(fmt.Errorf("watch stopped, resuming in %s: %w", backoff, err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if time.Since(start) > MaxBackoff {
+			// it ran for a while, so this is a fresh failure
+			backoff = time.Second
+		} else if backoff *= 2; backoff > MaxBackoff {
+			backoff = MaxBackoff
+		}
+	}
+}
+
+// session subscribes, fills the gap since the last seen block and then
+// delivers live events, following new heads, until a subscription fails.
+func (w *Watcher) session(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := &bind.WatchOpts{Context: ctx}
+
+	var (
+		transfers    = make(chan *token.ERC20tokenTransfer, 64)
+		approvals    = make(chan *token.ERC20tokenApproval, 64)
+		heads        = make(chan *types.Header, 16)
+		transferErrs <-chan error
+		approvalErrs <-chan error
+	)
+	headSub, err := w.backend.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer headSub.Unsubscribe()
+	if w.Transfers {
+		sub, err := w.filterer.WatchTransfer(opts, transfers, w.From, w.To)
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+		transferErrs = sub.Err()
+	}
+	if w.Approvals {
+		sub, err := w.filterer.WatchApproval(opts, approvals, w.Owner, w.Spender)
+		if err != nil {
+			return err
+		}
+		defer sub.Unsubscribe()
+		approvalErrs = sub.Err()
+	}
+
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if w.last != nil {
+		if err := w.backfill(ctx, *w.last, head.Number.Uint64()); err != nil {
+			return err
+		}
+	}
+	w.seen(head.Number.Uint64())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-headSub.Err():
+			return subscriptionErr(err)
+		case h := <-heads:
+			w.seen(h.Number.Uint64())
+		case err := <-transferErrs:
+			return subscriptionErr(err)
+		case err := <-approvalErrs:
+			return subscriptionErr(err)
+		case e := <-transfers:
+			if err := w.deliver(ctx, w.transfer(e)); err != nil {
+				return err
+			}
+		case e := <-approvals:
+			if err := w.deliver(ctx, w.approval(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func subscriptionErr(err error) error {
+	if err == nil {
+		return errors.New("subscription closed")
+	}
+	return err
+}
+
+// backfill delivers the events in blocks from to to that were missed while
+// the subscription was down, in ranges the node accepts.
+func (w *Watcher) backfill(ctx context.Context, from, to uint64) error {
+	err := erc20kit.WalkLogRanges(ctx, from, to, erc20kit.DefaultLogChunk, func(from, to uint64) error {
+		return w.backfillRange(ctx, from, to)
+	})
+	if err != nil {
+		return fmt.Errorf("backfill %d-%d: %w", from, to, err)
+	}
+	return nil
+}
+
+func (w *Watcher) backfillRange(ctx context.Context, from, to uint64) error {
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: ctx}
+	if w.Transfers {
+		it, err := w.filterer.FilterTransfer(opts, w.From, w.To)
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			if err := w.deliver(ctx, w.transfer(it.Event)); err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	if w.Approvals {
+		it, err := w.filterer.FilterApproval(opts, w.Owner, w.Spender)
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			if err := w.deliver(ctx, w.approval(it.Event)); err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) transfer(e *token.ERC20tokenTransfer) Notification {
+	n := w.notification(EventTransfer, e.Value, e.Raw)
+	n.From, n.To = e.From.Hex(), e.To.Hex()
+	return n
+}
+
+func (w *Watcher) approval(e *token.ERC20tokenApproval) Notification {
+	n := w.notification(EventApproval, e.Value, e.Raw)
+	n.Owner, n.Spender = e.Owner.Hex(), e.Spender.Hex()
+	return n
+}
+
+func (w *Watcher) notification(kind string, value *big.Int, raw types.Log) Notification {
+	return Notification{
+		Event:    kind,
+		Token:    w.Token.Hex(),
+		Amount:   erc20kit.ToDecimal(value, w.Decimals).String(),
+		Value:    value.String(),
+		Block:    raw.BlockNumber,
+		TxHash:   raw.TxHash.Hex(),
+		LogIndex: raw.Index,
+		Removed:  raw.Removed,
+	}
+}
+
+// keepBlocks is how many blocks below the newest seen one delivered
+// events are remembered for.
+const keepBlocks = 128
+
+// deliver sends n to the sink unless it was delivered already. When the
+// sink fails, the followed position goes back to n's block, so that the
+// backfill of the next session sends n again rather than losing it.
+func (w *Watcher) deliver(ctx context.Context, n Notification) error {
+	key := eventKey{block: n.Block, tx: n.TxHash, logIndex: n.LogIndex, removed: n.Removed}
+	if w.delivered[key] {
+		return nil
+	}
+	if err := w.Sink.Send(ctx, n); err != nil {
+		if w.last == nil || n.Block < *w.last {
+			block := n.Block
+			w.last = &block
+		}
+		return fmt.Errorf("deliver %s in tx %s: %w", n.Event, n.TxHash, err)
+	}
+	w.delivered[key] = true
+	// a log can come back, and go again, when the chain reorgs back
+	delete(w.delivered, eventKey{block: n.Block, tx: n.TxHash, logIndex: n.LogIndex, removed: !n.Removed})
+	w.seen(n.Block)
+	return nil
+}
+
+// seen moves the followed chain position up to block and forgets the
+// delivered events far below it.
+func (w *Watcher) seen(block uint64) {
+	if w.last != nil && block <= *w.last {
+		return
+	}
+	w.last = &block
+	for k := range w.delivered {
+		if k.block+keepBlocks < block {
+			delete(w.delivered, k)
+		}
+	}
+}
+
+func (w *Watcher) report(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
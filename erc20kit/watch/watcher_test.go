@@ -0,0 +1,231 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"gb-sc-homework/erc20kit"
+	"gb-sc-homework/erc20kit/simulated"
+	"gb-sc-homework/erc20kit/watch"
+)
+
+// chanSink hands notifications to the test.
+type chanSink chan watch.Notification
+
+func (c chanSink) Send(ctx context.Context, n watch.Notification) error {
+	select {
+	case c <- n:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TestWatchTransfers subscribes to transfers from the deployer and checks
+// that one comes through with its amount formatted. Transfers are repeated
+// until the subscription is up.
+func TestWatchTransfers(t *testing.T) {
+	chain := simulated.NewTestChain(t)
+	notifications := make(chanSink, 16)
+	w, err := watch.New(chain.Token, simulated.TokenDecimals, chain.Backend, notifications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Approvals = false
+	w.From = []common.Address{chain.Deployer.Address}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	for attempt := 0; attempt < 10; attempt++ {
+		_, err := chain.Deployer.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return chain.Instance.Transfer(opts, chain.User.Address, erc20kit.ToWei("0.5", simulated.TokenDecimals))
+		})
+		if err != nil {
+			t.Fatalf("transfer: %v", err)
+		}
+		select {
+		case n := <-notifications:
+			if n.Event != watch.EventTransfer || n.From != chain.Deployer.Address.Hex() || n.Amount != "0.5" {
+				t.Fatalf("unexpected notification %+v", n)
+			}
+			return
+		case err := <-done:
+			t.Fatalf("watch: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	t.Fatal("no notification")
+}
+
+// droppingBackend lets a test cut the head subscriptions of a watcher, as a
+// lost connection would.
+type droppingBackend struct {
+	*simulated.Backend
+	mu   sync.Mutex
+	subs []ethereum.Subscription
+}
+
+func (b *droppingBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub, err := b.Backend.SubscribeNewHead(ctx, ch)
+	if err == nil {
+		b.mu.Lock()
+		b.subs = append(b.subs, sub)
+		b.mu.Unlock()
+	}
+	return sub, err
+}
+
+func (b *droppingBackend) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		sub.Unsubscribe()
+	}
+	b.subs = nil
+}
+
+// TestWatchBackfill drops the subscriptions and checks that a transfer made
+// meanwhile is delivered once, and the ones before it are not repeated.
+func TestWatchBackfill(t *testing.T) {
+	chain := simulated.NewTestChain(t)
+	backend := &droppingBackend{Backend: chain.Backend}
+	notifications := make(chanSink, 16)
+	w, err := watch.New(chain.Token, simulated.TokenDecimals, backend, notifications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Approvals = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	transfer := func(amount string) string {
+		tx, err := chain.Deployer.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return chain.Instance.Transfer(opts, chain.User.Address, erc20kit.ToWei(amount, simulated.TokenDecimals))
+		})
+		if err != nil {
+			t.Fatalf("transfer: %v", err)
+		}
+		return tx.Hash().Hex()
+	}
+	next := func(wait time.Duration) (watch.Notification, bool) {
+		select {
+		case n := <-notifications:
+			return n, true
+		case err := <-done:
+			t.Fatalf("watch: %v", err)
+		case <-time.After(wait):
+		}
+		return watch.Notification{}, false
+	}
+
+	// sent tells for each transfer whether it was delivered; the ones made
+	// before the subscription was up may come with the backfill
+	sent := make(map[string]bool)
+	receive := func(first, rest time.Duration) {
+		for n, ok := next(first); ok; n, ok = next(rest) {
+			if delivered, ok := sent[n.TxHash]; !ok || delivered {
+				t.Fatalf("unexpected or repeated notification %+v", n)
+			}
+			sent[n.TxHash] = true
+		}
+	}
+	live := false
+	for attempt := 0; attempt < 10 && !live; attempt++ {
+		sent[transfer("1")] = false
+		receive(200*time.Millisecond, 50*time.Millisecond)
+		for _, delivered := range sent {
+			live = live || delivered
+		}
+	}
+	if !live {
+		t.Fatal("no live notification")
+	}
+
+	backend.drop()
+	missed := transfer("2")
+	sent[missed] = false
+	receive(5*time.Second, time.Second)
+	if !sent[missed] {
+		t.Fatal("transfer made while dropped was not delivered")
+	}
+}
+
+// failingSink fails its first delivery and passes the rest to chanSink.
+type failingSink struct {
+	chanSink
+	mu     sync.Mutex
+	failed chan string
+}
+
+func (s *failingSink) Send(ctx context.Context, n watch.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed != nil {
+		s.failed <- n.TxHash
+		s.failed = nil
+		return errors.New("503 Service Unavailable")
+	}
+	return s.chanSink.Send(ctx, n)
+}
+
+// TestWatchRedelivers fails the delivery of a transfer and checks that the
+// watcher resumes from it instead of dropping it.
+func TestWatchRedelivers(t *testing.T) {
+	chain := simulated.NewTestChain(t)
+	failed := make(chan string, 1)
+	sink := &failingSink{chanSink: make(chanSink, 16), failed: failed}
+	w, err := watch.New(chain.Token, simulated.TokenDecimals, chain.Backend, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Approvals = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	var lost string
+	for attempt := 0; attempt < 10 && lost == ""; attempt++ {
+		_, err := chain.Deployer.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return chain.Instance.Transfer(opts, chain.User.Address, erc20kit.ToWei("1", simulated.TokenDecimals))
+		})
+		if err != nil {
+			t.Fatalf("transfer: %v", err)
+		}
+		select {
+		case lost = <-failed:
+		case err := <-done:
+			t.Fatalf("watch: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	if lost == "" {
+		t.Fatal("no delivery attempted")
+	}
+
+	select {
+	case n := <-sink.chanSink:
+		if n.TxHash != lost {
+			t.Fatalf("first delivery after the failure is %s, want %s", n.TxHash, lost)
+		}
+	case err := <-done:
+		t.Fatalf("watch: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed transfer was not delivered again")
+	}
+}
@@ -1,240 +1,113 @@
 package main
 
 import (
-	"context"
-	"crypto/ecdsa"
+	"flag"
 	"fmt"
 	"log"
-	"math/big"
 	"os"
+	"strings"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/joho/godotenv"
-	"github.com/shopspring/decimal"
 
-	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
 )
 
 type config struct {
+	// DeployerAccount and UserAccount name the key of each alias:
+	// keystore:<file>, mnemonic:<hd path>, clef:<address> or the address
+	// of a file in KeystoreDir. The raw hex keys are only used without one.
+	DeployerAccount  string `env:"DEPLOYER_ACCOUNT"`
+	UserAccount      string `env:"USER_ACCOUNT"`
+	KeystoreDir      string `env:"KEYSTORE_DIR"`
+	KeystorePassword string `env:"KEYSTORE_PASSWORD"`
+	Mnemonic         string `env:"MNEMONIC"`
+	MnemonicPassword string `env:"MNEMONIC_PASSWORD"`
+	// ClefURL is the http(s) URL or IPC path of an external signer.
+	ClefURL        string `env:"CLEF_URL"`
 	PrivateKey     string `env:"PRIVATE_KEY"`
 	UserPrivateKey string `env:"USER_PRIVATE_KEY"`
-	RpcNode        string `env:"BSCTESTNET_URL"`
-}
-
-type Account struct {
-	PrivateKey ecdsa.PrivateKey
-	PublicKey  ecdsa.PublicKey
-	Address    common.Address
-	Auth       *bind.TransactOpts
-}
-
-func ToDecimal(ivalue interface{}, decimals int) decimal.Decimal {
-	value := new(big.Int)
-	switch v := ivalue.(type) {
-	case string:
-		value.SetString(v, 10)
-	case *big.Int:
-		value = v
-	}
-
-	mul := decimal.NewFromFloat(float64(10)).Pow(decimal.NewFromFloat(float64(decimals)))
-	num, _ := decimal.NewFromString(value.String())
-	result := num.Div(mul)
-
-	return result
-}
-
-// ToWei decimals to wei
-func ToWei(iamount interface{}, decimals int) *big.Int {
-	amount := decimal.NewFromFloat(0)
-	switch v := iamount.(type) {
-	case string:
-		amount, _ = decimal.NewFromString(v)
-	case float64:
-		amount = decimal.NewFromFloat(v)
-	case int64:
-		amount = decimal.NewFromFloat(float64(v))
-	case decimal.Decimal:
-		amount = v
-	case *decimal.Decimal:
-		amount = *v
-	}
-
-	mul := decimal.NewFromFloat(float64(10)).Pow(decimal.NewFromFloat(float64(decimals)))
-	result := amount.Mul(mul)
-
-	wei := new(big.Int)
-	wei.SetString(result.String(), 10)
-
-	return wei
-}
 
-func getClient(rpcNode string) *ethclient.Client {
-
-	client, err := ethclient.Dial(rpcNode)
-	if err != nil {
-		log.Fatal(err)
-	}
+	RpcNode       string `env:"RPC_URL"`
+	TokenAddress  string `env:"TOKEN_ADDRESS"`
+	TokenRegistry string `env:"TOKEN_REGISTRY"`
 
-	fmt.Println("we have a connection")
-	return client
+	// Network is the profile selected with --network or NETWORK. RpcNode
+	// is its first RPC URL.
+	Network erc20kit.Network
 }
 
-func getAccount(privateKeyStr string, client *ethclient.Client) Account {
-
-	privateKey, err := crypto.HexToECDSA(privateKeyStr)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("error casting public key to ECDSA")
-	}
-
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-
-	chainID, err := client.NetworkID(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
-	if err != nil {
-		log.Fatal(err)
-	}
-	auth.Nonce = nil
-	auth.Value = big.NewInt(0)     // in wei
-	auth.GasLimit = uint64(300000) // in units
-	auth.GasPrice = gasPrice
-
-	account := Account{
-		PrivateKey: *privateKey,
-		PublicKey:  *publicKeyECDSA,
-		Address:    address,
-		Auth:       auth,
+func loadConfig() config {
+	cfg := config{}
+	godotenv.Load(".env")
+	cfg.DeployerAccount = os.Getenv("DEPLOYER_ACCOUNT")
+	cfg.UserAccount = os.Getenv("USER_ACCOUNT")
+	cfg.KeystoreDir = os.Getenv("KEYSTORE_DIR")
+	if cfg.KeystoreDir == "" {
+		cfg.KeystoreDir = "keystore"
+	}
+	cfg.KeystorePassword = os.Getenv("KEYSTORE_PASSWORD")
+	cfg.Mnemonic = os.Getenv("MNEMONIC")
+	cfg.MnemonicPassword = os.Getenv("MNEMONIC_PASSWORD")
+	cfg.ClefURL = os.Getenv("CLEF_URL")
+	cfg.PrivateKey = os.Getenv("DEPLOYER_PRIVATE_KEY")
+	cfg.UserPrivateKey = os.Getenv("USER_PRIVATE_KEY")
+	cfg.RpcNode = os.Getenv("RPC_URL")
+	cfg.TokenAddress = os.Getenv("TOKEN_ADDRESS")
+	cfg.TokenRegistry = os.Getenv("TOKEN_REGISTRY")
+	if cfg.TokenRegistry == "" {
+		cfg.TokenRegistry = "tokens.json"
 	}
-	return account
+	return cfg
 }
 
-func checkTransactionReceipt(_txHash string, client ethclient.Client) int {
-	txHash := common.HexToHash(_txHash)
-	tx, err := client.TransactionReceipt(context.Background(), txHash)
+// selectNetwork loads a network profile. RPC_URL, a comma separated list,
+// replaces the profile's nodes; BSCTESTNET_URL still does for bsc-testnet.
+func (cfg *config) selectNetwork(name string) error {
+	network, err := erc20kit.LookupNetwork(name)
 	if err != nil {
-		return (-1)
+		return err
 	}
-	return (int(tx.Status))
-}
-
-func WaitForBlockCompletion(client ethclient.Client, hashToRead string) int {
-	soc := make(chan *types.Header)
-	sub, err := client.SubscribeNewHead(context.Background(), soc)
-	if err != nil {
-		log.Fatal(err)
-		return -1
+	urls := cfg.RpcNode
+	if urls == "" && network.Name == "bsc-testnet" {
+		urls = os.Getenv("BSCTESTNET_URL")
 	}
-
-	for {
-		select {
-		case err := <-sub.Err():
-			_ = err
-			return -1
-		case header := <-soc:
-			fmt.Println(header.TxHash.Hex())
-			transactionStatus := checkTransactionReceipt(hashToRead, client)
-			if transactionStatus == 0 {
-				//FAILURE
-				sub.Unsubscribe()
-				return 0
-			} else if transactionStatus == 1 {
-				//SUCCESS
-				sub.Unsubscribe()
-				return 1
-			}
+	if urls != "" {
+		network.RPCURLs = strings.Split(urls, ",")
+		for i := range network.RPCURLs {
+			network.RPCURLs[i] = strings.TrimSpace(network.RPCURLs[i])
 		}
 	}
+	cfg.Network = network
+	cfg.RpcNode = network.RPCURLs[0]
+	return nil
 }
 
 func main() {
-	cfg := config{}
-	godotenv.Load(".env")
-	cfg.PrivateKey = os.Getenv("DEPLOYER_PRIVATE_KEY")
-	cfg.UserPrivateKey = os.Getenv("USER_PRIVATE_KEY")
-	cfg.RpcNode = os.Getenv("BSCTESTNET_URL")
-
-	client := getClient(cfg.RpcNode)
-
-	deployer := getAccount(cfg.PrivateKey, client)
-	user := getAccount(cfg.UserPrivateKey, client)
-	fmt.Println("Deployer address: ", deployer.Address.String())
-	fmt.Println("User address: ", user.Address.String())
+	cfg := loadConfig()
 
-	tokenAddress := common.HexToAddress("0x8e374AbDFecEf1203BFC142FCA2E93819C98f2fC")
-	tokenInstance, err := token.NewERC20token(tokenAddress, client)
-	if err != nil {
-		log.Fatal(err)
+	global := flag.NewFlagSet("gb-sc-homework", flag.ExitOnError)
+	global.Usage = printUsage
+	network := global.String("network", os.Getenv("NETWORK"), "network profile (defaults to NETWORK, then bsc-testnet)")
+	global.Parse(os.Args[1:])
+	if global.NArg() < 1 {
+		printUsage()
+		os.Exit(2)
 	}
-
-	decimals, err := tokenInstance.Decimals(&bind.CallOpts{})
-	if err != nil {
-		log.Fatal(err)
+	if *network == "" {
+		*network = "bsc-testnet"
 	}
-	fmt.Println("decimals: ", decimals) // "decimals: 18"
-
-	//show balances
-	deployerBalance, _ := tokenInstance.BalanceOf(&bind.CallOpts{}, deployer.Address)
-	userBalance, _ := tokenInstance.BalanceOf(&bind.CallOpts{}, user.Address)
-
-	fmt.Println("Deployer balance: ", ToDecimal(deployerBalance, int(decimals))) // "balance: 74605500.647409"
-	fmt.Println("User balance: ", ToDecimal(userBalance, int(decimals)))         // "balance: 74605500.647409"
-
-	//sending 100 tokens from deployer to user
-	amount := ToWei(100.0, int(decimals))
-	fmt.Println(amount)
-	tx, err := tokenInstance.Transfer(deployer.Auth, user.Address, amount)
-	if err != nil {
+	if err := cfg.selectNetwork(*network); err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("tx sent: %s\n", tx.Hash().Hex())
-	WaitForBlockCompletion(*client, tx.Hash().String())
 
-	//show balances
-	deployerBalance, _ = tokenInstance.BalanceOf(&bind.CallOpts{}, deployer.Address)
-	userBalance, _ = tokenInstance.BalanceOf(&bind.CallOpts{}, user.Address)
-
-	fmt.Println("Deployer balance: ", ToDecimal(deployerBalance, int(decimals))) // "balance: 74605500.647409"
-	fmt.Println("User balance: ", ToDecimal(userBalance, int(decimals)))         // "balance: 74605500.647409"
-
-	tx, err = tokenInstance.Approve(user.Auth, deployer.Address, amount)
-	if err != nil {
-		log.Fatal(err)
+	cmd, ok := findCommand(global.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", global.Arg(0))
+		printUsage()
+		os.Exit(2)
 	}
-	fmt.Printf("tx sent: %s\n", tx.Hash().Hex())
-	WaitForBlockCompletion(*client, tx.Hash().String())
 
-	amount = ToWei(10.0, int(decimals))
-	tx, err = tokenInstance.TransferFrom(deployer.Auth, user.Address, deployer.Address, amount)
-	if err != nil {
+	if err := cmd.run(cfg, global.Args()[1:]); err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("tx sent: %s\n", tx.Hash().Hex())
-	WaitForBlockCompletion(*client, tx.Hash().String())
-
-	//show balances
-	deployerBalance, _ = tokenInstance.BalanceOf(&bind.CallOpts{}, deployer.Address)
-	userBalance, _ = tokenInstance.BalanceOf(&bind.CallOpts{}, user.Address)
-
-	fmt.Println("Deployer balance: ", ToDecimal(deployerBalance, int(decimals))) // "balance: 74605500.647409"
-	fmt.Println("User balance: ", ToDecimal(userBalance, int(decimals)))         // "balance: 74605500.647409"
-
 }
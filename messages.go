@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// runSignMessage signs an EIP-191 personal message, as personal_sign
+// does. Like `sign` it needs no node.
+func runSignMessage(cfg config, args []string) error {
+	fs := flag.NewFlagSet("sign-message", flag.ExitOnError)
+	from := fs.String("from", "user", "account alias or address that signs")
+	mf := addMessageFlags(fs)
+	fs.Parse(args)
+
+	message, err := mf.read(fs)
+	if err != nil {
+		return err
+	}
+	signer, err := openSigner(cfg, *from)
+	if err != nil {
+		return err
+	}
+	messageSigner, ok := signer.(erc20kit.MessageSigner)
+	if !ok {
+		return fmt.Errorf("signer of %s cannot sign messages", signer.Address().Hex())
+	}
+	signature, err := messageSigner.SignMessage(context.Background(), message)
+	if err != nil {
+		return err
+	}
+	printSignature(signer.Address(), erc20kit.MessageHash(message), signature)
+	return nil
+}
+
+// runSignTypedData signs an EIP-712 payload in the JSON form of
+// eth_signTypedData_v4.
+func runSignTypedData(cfg config, args []string) error {
+	fs := flag.NewFlagSet("sign-typed-data", flag.ExitOnError)
+	from := fs.String("from", "user", "account alias or address that signs")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: sign-typed-data [--from user] <payload.json>")
+	}
+
+	data, err := readTypedData(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	hash, err := erc20kit.TypedDataHash(data)
+	if err != nil {
+		return err
+	}
+	if chainID := data.Domain.ChainId; chainID != nil && (*hexutil.Big)(chainID).ToInt().Cmp(cfg.Network.ChainID) != 0 {
+		fmt.Fprintf(os.Stderr, "warning: the domain is for chain %s, network %s is chain %s\n", (*hexutil.Big)(chainID).ToInt(), cfg.Network.Name, cfg.Network.ChainID)
+	}
+	signer, err := openSigner(cfg, *from)
+	if err != nil {
+		return err
+	}
+	typedSigner, ok := signer.(erc20kit.TypedDataSigner)
+	if !ok {
+		return fmt.Errorf("signer of %s cannot sign typed data", signer.Address().Hex())
+	}
+	signature, err := typedSigner.SignTypedData(context.Background(), data)
+	if err != nil {
+		return err
+	}
+	printSignature(signer.Address(), hash, signature)
+	return nil
+}
+
+// runVerify recovers the signer of a personal message or of typed data
+// and, with --expect, fails unless it is that account.
+func runVerify(cfg config, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	signatureHex := fs.String("signature", "", "0x-prefixed 65 byte signature")
+	expect := fs.String("expect", "", "alias or address the signature must be from")
+	typedData := fs.String("typed-data", "", "verify a signature of this EIP-712 payload instead of a message")
+	mf := addMessageFlags(fs)
+	fs.Parse(args)
+
+	signature, err := hexutil.Decode(*signatureHex)
+	if err != nil {
+		return fmt.Errorf("invalid --signature: %w", err)
+	}
+	var (
+		signer common.Address
+		hash   common.Hash
+	)
+	if *typedData != "" {
+		data, err := readTypedData(*typedData)
+		if err != nil {
+			return err
+		}
+		if hash, err = erc20kit.TypedDataHash(data); err != nil {
+			return err
+		}
+		signer, err = erc20kit.RecoverTypedData(data, signature)
+		if err != nil {
+			return err
+		}
+	} else {
+		message, err := mf.read(fs)
+		if err != nil {
+			return err
+		}
+		hash = erc20kit.MessageHash(message)
+		if signer, err = erc20kit.RecoverMessage(message, signature); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%-12s %s\n", "hash:", hash.Hex())
+	fmt.Printf("%-12s %s\n", "signer:", signer.Hex())
+
+	if *expect == "" {
+		return nil
+	}
+	expected, err := resolveAddress(cfg, *expect)
+	if err != nil {
+		return err
+	}
+	if signer != expected {
+		return fmt.Errorf("%w: signature is from %s, not %s", erc20kit.ErrBadSigner, signer.Hex(), expected.Hex())
+	}
+	fmt.Printf("signature is from %s\n", *expect)
+	return nil
+}
+
+// messageFlags say where the message of sign-message and verify comes
+// from: the argument or a file, as text or as hex.
+type messageFlags struct {
+	file  string
+	isHex bool
+}
+
+func addMessageFlags(fs *flag.FlagSet) *messageFlags {
+	mf := &messageFlags{}
+	fs.StringVar(&mf.file, "file", "", "take the message from this file instead of the argument")
+	fs.BoolVar(&mf.isHex, "hex", false, "the message is 0x-prefixed hex bytes rather than text")
+	return mf
+}
+
+func (mf *messageFlags) read(fs *flag.FlagSet) ([]byte, error) {
+	var message []byte
+	switch {
+	case mf.file != "" && fs.NArg() == 0:
+		data, err := os.ReadFile(mf.file)
+		if err != nil {
+			return nil, err
+		}
+		message = data
+	case mf.file == "" && fs.NArg() == 1:
+		message = []byte(fs.Arg(0))
+	default:
+		return nil, fmt.Errorf("usage: %s [flags] (<message> | --file path)", fs.Name())
+	}
+	if !mf.isHex {
+		return message, nil
+	}
+	decoded, err := hexutil.Decode(strings.TrimSpace(string(message)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex message: %w", err)
+	}
+	return decoded, nil
+}
+
+func readTypedData(path string) (apitypes.TypedData, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return apitypes.TypedData{}, err
+	}
+	data, err := erc20kit.ParseTypedData(payload)
+	if err != nil {
+		return apitypes.TypedData{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+func printSignature(signer common.Address, hash common.Hash, signature []byte) {
+	fmt.Printf("%-12s %s\n", "signer:", signer.Hex())
+	fmt.Printf("%-12s %s\n", "hash:", hash.Hex())
+	fmt.Printf("%-12s %s\n", "signature:", hexutil.Encode(signature))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"gb-sc-homework/erc20kit"
+)
+
+// runSendNative sends the network's own coin, signed the same way as the
+// token transactions.
+func runSendNative(cfg config, args []string) error {
+	fs := flag.NewFlagSet("send-native", flag.ExitOnError)
+	from := fs.String("from", "deployer", "sending account alias")
+	to := fs.String("to", "", "recipient alias or address")
+	amountStr := fs.String("amount", "", fmt.Sprintf("amount in whole %s, e.g. 0.5", cfg.Network.Currency))
+	tf := addTxFlags(fs, cfg)
+	tf.addExportFlag(fs)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	recipient, err := resolveAddress(cfg, *to)
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(*amountStr, 18)
+	if err != nil {
+		return err
+	}
+	description := fmt.Sprintf("send %s %s to %s", erc20kit.ToDecimal(amount, 18), cfg.Network.Currency, recipient.Hex())
+
+	if tf.export != "" {
+		sender, err := resolveAddress(cfg, *from)
+		if err != nil {
+			return err
+		}
+		return exportTx(ctx, cfg, client, tf, *from, description, erc20kit.NativeTransfer(client, sender, recipient, amount))
+	}
+	sender, err := resolveSigner(ctx, cfg, client, *from)
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(sender); err != nil {
+		return err
+	}
+
+	if tf.dryRun {
+		return previewTx(ctx, cfg, client, sender, description, erc20kit.NativeTransfer(client, sender.Address, recipient, amount))
+	}
+	fmt.Println(description)
+	tx, err := sender.SendNative(ctx, recipient, amount)
+	if err != nil {
+		return err
+	}
+	return waitForTx(ctx, client, tx, tf)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// runSign signs a transaction written by --export. It never dials a node,
+// so it can run on a machine without network access.
+func runSign(cfg config, args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	out := fs.String("out", "", "file to write the signed transaction to (defaults to <input>.signed.json)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: sign [--out file] <tx.json>")
+	}
+	in := fs.Arg(0)
+	if *out == "" {
+		*out = strings.TrimSuffix(in, ".json") + ".signed.json"
+	}
+
+	var unsigned erc20kit.UnsignedTx
+	if err := readJSONFile(in, &unsigned); err != nil {
+		return err
+	}
+	if unsigned.Tx.ChainID == nil {
+		return fmt.Errorf("%s: transaction has no chain id", in)
+	}
+	if chainID := unsigned.Tx.ChainID.ToInt(); chainID.Cmp(cfg.Network.ChainID) != 0 {
+		return fmt.Errorf("%w: transaction is for chain %s, network %s is chain %s", erc20kit.ErrWrongChain, chainID, cfg.Network.Name, cfg.Network.ChainID)
+	}
+	if err := printUnsigned(cfg, &unsigned); err != nil {
+		return err
+	}
+
+	signer, err := openSigner(cfg, unsigned.Tx.From.Address().Hex())
+	if err != nil {
+		return err
+	}
+	signed, err := unsigned.Sign(context.Background(), signer)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONFile(*out, signed); err != nil {
+		return err
+	}
+	fmt.Printf("signed tx %s written to %s\n", signed.Hash.Hex(), *out)
+	return nil
+}
+
+// printUnsigned shows what is about to be signed, with token calls
+// decoded, so it can be checked on the signing machine itself.
+func printUnsigned(cfg config, unsigned *erc20kit.UnsignedTx) error {
+	tx := unsigned.Tx
+	if unsigned.Description != "" {
+		fmt.Printf("%-12s %s\n", "description:", unsigned.Description)
+	}
+	fmt.Printf("%-12s %s\n", "from:", tx.From.Address().Hex())
+	if tx.To != nil {
+		fmt.Printf("%-12s %s\n", "to:", tx.To.Address().Hex())
+	}
+	parsed, err := token.ERC20tokenMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	data := tx.ToTransaction().Data()
+	if call := erc20kit.DecodeCall(data, parsed); call != "" {
+		fmt.Printf("%-12s %s\n", "call:", call)
+	} else if len(data) > 0 {
+		fmt.Printf("%-12s %d bytes\n", "data:", len(data))
+	}
+	fmt.Printf("%-12s %s %s\n", "value:", erc20kit.ToDecimal(tx.Value.ToInt(), 18), cfg.Network.Currency)
+	fmt.Printf("%-12s %d\n", "nonce:", uint64(tx.Nonce))
+
+	gas := new(big.Int).SetUint64(uint64(tx.Gas))
+	price := tx.GasPrice
+	if tx.MaxFeePerGas != nil {
+		price = tx.MaxFeePerGas
+		fmt.Printf("%-12s %d at most %s gwei, tip %s gwei\n", "gas:", gas, erc20kit.ToDecimal(price.ToInt(), 9), erc20kit.ToDecimal(tx.MaxPriorityFeePerGas.ToInt(), 9))
+	} else if price != nil {
+		fmt.Printf("%-12s %d at %s gwei\n", "gas:", gas, erc20kit.ToDecimal(price.ToInt(), 9))
+	}
+	if price != nil {
+		maxCost := new(big.Int).Mul(gas, price.ToInt())
+		fmt.Printf("%-12s %s %s\n", "max cost:", erc20kit.ToDecimal(maxCost.Add(maxCost, tx.Value.ToInt()), 18), cfg.Network.Currency)
+	}
+	fmt.Printf("%-12s %s (%s)\n", "chain:", cfg.Network.ChainID, cfg.Network.Name)
+	return nil
+}
+
+// runBroadcast sends a transaction signed with `sign` and waits for it.
+func runBroadcast(cfg config, args []string) error {
+	fs := flag.NewFlagSet("broadcast", flag.ExitOnError)
+	tf := addWaitFlags(fs, cfg)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: broadcast [flags] <tx.signed.json>")
+	}
+
+	var signed erc20kit.SignedTx
+	if err := readJSONFile(fs.Arg(0), &signed); err != nil {
+		return err
+	}
+	tx, err := signed.Transaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if tx.ChainId().Cmp(cfg.Network.ChainID) != 0 {
+		return fmt.Errorf("%w: transaction is for chain %s, network %s is chain %s", erc20kit.ErrWrongChain, tx.ChainId(), cfg.Network.Name, cfg.Network.ChainID)
+	}
+
+	ctx := context.Background()
+	client, err := dial(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := cfg.Network.CheckChain(ctx, client); err != nil {
+		return err
+	}
+	if signed.Description != "" {
+		fmt.Println(signed.Description)
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		// broadcasting the same file again finds the transaction known
+		if _, _, lookupErr := client.TransactionByHash(ctx, tx.Hash()); lookupErr != nil {
+			return fmt.Errorf("broadcast %s: %w", tx.Hash().Hex(), err)
+		}
+		fmt.Printf("tx %s was already broadcast\n", tx.Hash().Hex())
+	}
+	return waitForTx(ctx, client, tx, tf)
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	token "gb-sc-homework/contracts/IERC20"
+	"gb-sc-homework/erc20kit"
+)
+
+// runPermit lets the owner approve the spender by signature instead of
+// paying for an approve transaction. The spender submits the permit and
+// pulls the tokens with TransferFrom. Tokens without EIP-2612 fall back
+// to approve sent by the owner.
+func runPermit(cfg config, args []string) error {
+	fs, tokenAddr := newFlagSet("permit", cfg)
+	from := fs.String("from", "user", "owner account alias that signs the permit")
+	spender := fs.String("spender", "deployer", "alias or address that submits the permit and pulls the tokens")
+	to := fs.String("to", "", "recipient of the TransferFrom (defaults to the spender)")
+	amountStr := fs.String("amount", "", "allowance in whole tokens")
+	validFor := fs.Duration("deadline", time.Hour, "how long the permit stays valid")
+	out := fs.String("out", "", "write the signed permit to this file for the spender instead of submitting it")
+	submit := fs.String("submit", "", "submit a permit written with --out instead of signing one")
+	tf := addTxFlags(fs, cfg)
+	fs.Parse(args)
+
+	ctx := context.Background()
+	ts, err := openToken(ctx, cfg, *tokenAddr)
+	if err != nil {
+		return err
+	}
+	defer ts.client.Close()
+
+	var permit *erc20kit.Permit
+	if *submit != "" {
+		permit = new(erc20kit.Permit)
+		if err := readJSONFile(*submit, permit); err != nil {
+			return err
+		}
+		if permit.Token() != ts.address {
+			return fmt.Errorf("%s: permit is for token %s, not %s", *submit, permit.Token().Hex(), ts.address.Hex())
+		}
+	} else {
+		spenderAddress, err := resolveAddress(cfg, *spender)
+		if err != nil {
+			return err
+		}
+		amount, err := parseAmount(*amountStr, ts.decimals)
+		if err != nil {
+			return err
+		}
+		owner, err := resolveSigner(ctx, cfg, ts.client, *from)
+		if err != nil {
+			return err
+		}
+		permit, err = owner.SignPermit(ctx, ts.address, spenderAddress, amount, time.Now().Add(*validFor))
+		if errors.Is(err, erc20kit.ErrNoPermit) {
+			if *out != "" {
+				return fmt.Errorf("%s: %w, the owner has to run `approve` instead", ts.symbol, err)
+			}
+			fmt.Printf("%s does not support permit (%v), falling back to approve\n", ts.symbol, err)
+			return approveAndPull(ctx, cfg, ts, tf, owner, spenderAddress, *to, amount)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("permit signed: %s may spend %s of %s until %s\n", permit.Spender.Hex(), ts.amount(amount), permit.Owner.Hex(), time.Unix(permit.Deadline.ToInt().Int64(), 0).Format(time.RFC3339))
+		if *out != "" {
+			if err := writeJSONFile(*out, permit); err != nil {
+				return err
+			}
+			fmt.Printf("permit written to %s\n", *out)
+			return nil
+		}
+	}
+
+	if err := permit.Verify(); err != nil {
+		return err
+	}
+	if permit.Expired(time.Now()) {
+		return fmt.Errorf("permit expired at %s", time.Unix(permit.Deadline.ToInt().Int64(), 0).Format(time.RFC3339))
+	}
+	signer, err := resolveSigner(ctx, cfg, ts.client, permit.Spender.Hex())
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(signer); err != nil {
+		return err
+	}
+	if tf.dryRun {
+		v, r, s := permit.VRS()
+		return simulateCall(ctx, ts, signer, token.IERC20PermitMetaData, "permit", permit.Owner, permit.Spender, permit.Value.ToInt(), permit.Deadline.ToInt(), v, r, s)
+	}
+
+	used, err := permit.Used(ctx, ts.client)
+	if err != nil {
+		return err
+	}
+	if used {
+		// someone submitted it first, which is fine if the allowance is there
+		fmt.Println("permit was already submitted")
+	} else {
+		tx, err := signer.SubmitPermit(ctx, permit)
+		if err != nil {
+			return err
+		}
+		if err := waitForTx(ctx, ts.client, tx, tf); err != nil {
+			return err
+		}
+	}
+	return pull(ctx, cfg, ts, tf, signer, permit.Owner, *to, permit.Value.ToInt())
+}
+
+// approveAndPull is the permit flow for tokens without permit: the owner
+// pays for setting the allowance.
+func approveAndPull(ctx context.Context, cfg config, ts *tokenSession, tf *txFlags, owner *erc20kit.Account, spender common.Address, to string, amount *big.Int) error {
+	if err := tf.apply(owner); err != nil {
+		return err
+	}
+	if tf.dryRun {
+		return simulateTx(ctx, ts, owner, "approve", spender, amount)
+	}
+	allowances, err := erc20kit.NewAllowances(owner, ts.address)
+	if err != nil {
+		return err
+	}
+	allowances.Wait = tf.wait
+	sent, err := allowances.Set(ctx, spender, amount)
+	if err != nil {
+		return err
+	}
+	if len(sent) > 0 {
+		if err := waitForTx(ctx, ts.client, sent[len(sent)-1], tf); err != nil {
+			return err
+		}
+	}
+	signer, err := resolveSigner(ctx, cfg, ts.client, spender.Hex())
+	if err != nil {
+		return err
+	}
+	if err := tf.apply(signer); err != nil {
+		return err
+	}
+	return pull(ctx, cfg, ts, tf, signer, owner.Address, to, amount)
+}
+
+// pull moves amount from owner to the recipient to, or to the spender
+// itself, with the allowance the spender was just given.
+func pull(ctx context.Context, cfg config, ts *tokenSession, tf *txFlags, spender *erc20kit.Account, owner common.Address, to string, amount *big.Int) error {
+	recipient := spender.Address
+	if to != "" {
+		var err error
+		if recipient, err = resolveAddress(cfg, to); err != nil {
+			return err
+		}
+	}
+	tx, err := spender.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ts.instance.TransferFrom(opts, owner, recipient, amount)
+	})
+	if err != nil {
+		return err
+	}
+	return waitForTx(ctx, ts.client, tx, tf)
+}